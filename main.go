@@ -5,7 +5,9 @@
 package main
 
 import (
+	"context"
 	"os"
+	"time"
 
 	"github.com/kberov/rowx/rx"
 )
@@ -17,8 +19,12 @@ func init() {
 
 func main() {
 	i := run()
-	if e := rx.DB().Close(); e != nil {
-		rx.Logger.Errorf("%s", e.Error())
+	// Migrate and Generate use their own connections. The default one is
+	// closed only if something opened it.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := rx.Close(ctx); err != nil {
+		rx.Logger.Error(err)
 	}
+	cancel()
 	os.Exit(i)
 }
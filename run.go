@@ -5,6 +5,9 @@ import (
 	"flag"
 	"io"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/gommon/log"
 	"github.com/valyala/fasttemplate"
@@ -15,14 +18,30 @@ import (
 const (
 	migrate  string = `migrate`
 	generate string = `generate`
+	erd      string = `erd`
+	lint     string = `lint-templates`
+	anon     string = `anonymize`
+	squash   string = `squash`
 )
 
 var (
 	mFlags, gFlags      *flag.FlagSet
+	eFlags, lFlags      *flag.FlagSet
+	aFlags, sFlags      *flag.FlagSet
 	dsn, sqlFilePath    string
+	driver              string
 	direction, logLevel string
 	packagePath, action string
+	erdFormat, table    string
 	tables2structs      string
+	where, nullColumns  string
+	hashColumns, out    string
+	templatesDir        string
+	suggestDown, check  bool
+	create, progress    bool
+	tests, binaryUUIDs  bool
+	dtos, handlers      bool
+	wait                time.Duration
 	output              io.Writer
 	logLevels           = map[string]log.Lvl{"DEBUG": 1, "INFO": 2, "WARN": 3, "ERROR": 4, "OFF": 5}
 )
@@ -33,17 +52,32 @@ func _init() {
 	mFlags = flag.NewFlagSet(migrate, flag.ContinueOnError)
 	mFlags.SetOutput(output)
 	mFlags.StringVar(&dsn, `dsn`, ``, `Database to connect to.`)
+	mFlags.StringVar(&driver, `driver`, `sqlite3`, "Database driver, e.g. sqlite3, pgx, mysql. The"+
+		" driver must be\n             compiled in. Default is sqlite3.")
+	mFlags.BoolVar(&create, `create`, false, "Create the SQLite or DuckDB database file, if it does"+
+		" not exist.\n             Without it a missing file is an error.")
 	mFlags.StringVar(&sqlFilePath, `sql_file`, ``, `Path to sql file for migration.`)
 	mFlags.StringVar(&direction, `direction`, ``, `Direction for migration: up or down.`)
 	mFlags.StringVar(&logLevel, `log_level`, `INFO`,
 		`One of DEBUG, INFO, WARN, ERROR, OFF. Default is INFO.`)
+	mFlags.BoolVar(&suggestDown, `suggest_down`, false, "Print generated down migrations for up migrations"+
+		" without\n               one and exit. Only 'sql_file' is needed.")
+	mFlags.DurationVar(&wait, `wait`, rx.MigrateWait, "How long to wait for a locked SQLite database,"+
+		" e.g. 30s.")
+	mFlags.BoolVar(&progress, `progress`, false, "Print the applied statements and statements per"+
+		" second\n             every second.")
 	mFlags.Usage = func() {
 		say(migrateTmpl, output, rx.Map{
 			migrate:          mFlags.Name(),
 			`sql_file_help`:  mFlags.Lookup(`sql_file`).Usage,
 			`mdsn_help`:      mFlags.Lookup(`dsn`).Usage,
+			`driver_help`:    mFlags.Lookup(`driver`).Usage,
+			`create_help`:    mFlags.Lookup(`create`).Usage,
 			`direction_help`: mFlags.Lookup(`direction`).Usage,
 			`ll_help`:        mFlags.Lookup(`log_level`).Usage,
+			`sd_help`:        mFlags.Lookup(`suggest_down`).Usage,
+			`wait_help`:      mFlags.Lookup(`wait`).Usage,
+			`progress_help`:  mFlags.Lookup(`progress`).Usage,
 		})
 	}
 
@@ -51,21 +85,150 @@ func _init() {
 	gFlags.SetOutput(output)
 	mdsn := mFlags.Lookup(`dsn`)
 	gFlags.StringVar(&dsn, mdsn.Name, mdsn.DefValue, mdsn.Usage)
+	mdriver := mFlags.Lookup(`driver`)
+	gFlags.StringVar(&driver, mdriver.Name, mdriver.DefValue, mdriver.Usage)
+	mcreate := mFlags.Lookup(`create`)
+	gFlags.BoolVar(&create, mcreate.Name, false, mcreate.Usage)
 	gFlags.StringVar(&packagePath, `package`, ``, "Path to package to generate."+
 		" Last folder is the name of\n             the package to be generated.")
 	gFlags.StringVar(&tables2structs, `tables`, tables2structs, `Comma-separated list of table-names
              for which to generate structs.`)
+	gFlags.StringVar(&templatesDir, `templates`, ``, "Directory with templates (e.g. struct.tmpl),"+
+		" overriding\n             rx.GeneratorTemplates.")
+	gFlags.BoolVar(&check, `check`, false, "Do not write files. Print a diff and exit with 3, if"+
+		" the generated\n             files are stale.")
+	gFlags.BoolVar(&tests, `tests`, false, "Generate also <package>_tables_test.go with round-trip"+
+		" tests\n             for every table. Only for SQLite.")
+	gFlags.BoolVar(&binaryUUIDs, `uuid`, false, "Map BLOB(16) and BINARY(16) columns to rx.UUID"+
+		" instead of\n             []byte.")
+	gFlags.BoolVar(&dtos, `dto`, false, "Generate also <package>_dto.go with JSON-friendly DTOs"+
+		" and\n             converters for every table.")
+	gFlags.BoolVar(&handlers, `handlers`, false, "Generate also <package>_handlers.go with net/http"+
+		" CRUD\n             handlers for every table with a column id.")
 	mLogLevel := mFlags.Lookup(`log_level`)
 	gFlags.StringVar(&logLevel, mLogLevel.Name, mLogLevel.DefValue, mLogLevel.Usage)
 	gFlags.Usage = func() {
 		say(generateTmpl, output, rx.Map{
-			generate:       gFlags.Name(),
-			`package_help`: gFlags.Lookup(`package`).Usage,
-			`gdsn_help`:    gFlags.Lookup(`dsn`).Usage,
-			`ll_help`:      gFlags.Lookup(`log_level`).Usage,
-			`tables_help`:  gFlags.Lookup(`tables`).Usage,
+			generate:        gFlags.Name(),
+			`package_help`:  gFlags.Lookup(`package`).Usage,
+			`gdsn_help`:     gFlags.Lookup(`dsn`).Usage,
+			`driver_help`:   gFlags.Lookup(`driver`).Usage,
+			`create_help`:   gFlags.Lookup(`create`).Usage,
+			`ll_help`:       gFlags.Lookup(`log_level`).Usage,
+			`tables_help`:   gFlags.Lookup(`tables`).Usage,
+			`tpl_help`:      gFlags.Lookup(`templates`).Usage,
+			`check_help`:    gFlags.Lookup(`check`).Usage,
+			`tests_help`:    gFlags.Lookup(`tests`).Usage,
+			`uuid_help`:     gFlags.Lookup(`uuid`).Usage,
+			`dto_help`:      gFlags.Lookup(`dto`).Usage,
+			`handlers_help`: gFlags.Lookup(`handlers`).Usage,
 		})
 	}
+	initERD()
+	initLint()
+	initAnonymize()
+	initSquash()
+}
+
+func initERD() {
+	eFlags = flag.NewFlagSet(erd, flag.ContinueOnError)
+	eFlags.SetOutput(output)
+	mdsn := mFlags.Lookup(`dsn`)
+	eFlags.StringVar(&dsn, mdsn.Name, mdsn.DefValue, mdsn.Usage)
+	mdriver := mFlags.Lookup(`driver`)
+	eFlags.StringVar(&driver, mdriver.Name, mdriver.DefValue, mdriver.Usage)
+	mcreate := mFlags.Lookup(`create`)
+	eFlags.BoolVar(&create, mcreate.Name, false, mcreate.Usage)
+	eFlags.StringVar(&erdFormat, `format`, `mermaid`, `One of mermaid, dot. Default is mermaid.`)
+	eFlags.Usage = func() {
+		say(erdTmpl, output, rx.Map{
+			erd:           eFlags.Name(),
+			`edsn_help`:   eFlags.Lookup(`dsn`).Usage,
+			`driver_help`: eFlags.Lookup(`driver`).Usage,
+			`create_help`: eFlags.Lookup(`create`).Usage,
+			`format_help`: eFlags.Lookup(`format`).Usage,
+		})
+	}
+}
+
+func initLint() {
+	lFlags = flag.NewFlagSet(lint, flag.ContinueOnError)
+	lFlags.SetOutput(output)
+	mdsn := mFlags.Lookup(`dsn`)
+	lFlags.StringVar(&dsn, mdsn.Name, mdsn.DefValue, mdsn.Usage)
+	mdriver := mFlags.Lookup(`driver`)
+	lFlags.StringVar(&driver, mdriver.Name, mdriver.DefValue, mdriver.Usage)
+	mcreate := mFlags.Lookup(`create`)
+	lFlags.BoolVar(&create, mcreate.Name, false, mcreate.Usage)
+	lFlags.StringVar(&table, `table`, ``, "Existing table, which columns are used to render"+
+		" the\n             templates.")
+	lFlags.Usage = func() {
+		say(lintTmpl, output, rx.Map{
+			lint:          lFlags.Name(),
+			`ldsn_help`:   lFlags.Lookup(`dsn`).Usage,
+			`driver_help`: lFlags.Lookup(`driver`).Usage,
+			`create_help`: lFlags.Lookup(`create`).Usage,
+			`table_help`:  lFlags.Lookup(`table`).Usage,
+		})
+	}
+}
+
+func initAnonymize() {
+	aFlags = flag.NewFlagSet(anon, flag.ContinueOnError)
+	aFlags.SetOutput(output)
+	mdsn := mFlags.Lookup(`dsn`)
+	aFlags.StringVar(&dsn, mdsn.Name, mdsn.DefValue, mdsn.Usage)
+	mdriver := mFlags.Lookup(`driver`)
+	aFlags.StringVar(&driver, mdriver.Name, mdriver.DefValue, mdriver.Usage)
+	mcreate := mFlags.Lookup(`create`)
+	aFlags.BoolVar(&create, mcreate.Name, false, mcreate.Usage)
+	aFlags.StringVar(&table, `table`, ``, `Table with the rows to anonymize.`)
+	aFlags.StringVar(&where, `where`, ``, `Condition, matching the rows, e.g. "id = 3".`)
+	aFlags.StringVar(&nullColumns, `null`, ``, "Comma-separated list of columns (or table.column)"+
+		" to set\n             to NULL.")
+	aFlags.StringVar(&hashColumns, `hash`, ``, "Comma-separated list of columns (or table.column)"+
+		" to replace\n             with their SHA-256 sums.")
+	aFlags.Usage = func() {
+		say(anonTmpl, output, anonHelp())
+	}
+}
+
+func anonHelp() rx.Map {
+	return rx.Map{
+		anon:          aFlags.Name(),
+		`adsn_help`:   aFlags.Lookup(`dsn`).Usage,
+		`driver_help`: aFlags.Lookup(`driver`).Usage,
+		`create_help`: aFlags.Lookup(`create`).Usage,
+		`atable_help`: aFlags.Lookup(`table`).Usage,
+		`where_help`:  aFlags.Lookup(`where`).Usage,
+		`null_help`:   aFlags.Lookup(`null`).Usage,
+		`hash_help`:   aFlags.Lookup(`hash`).Usage,
+	}
+}
+
+func initSquash() {
+	sFlags = flag.NewFlagSet(squash, flag.ContinueOnError)
+	sFlags.SetOutput(output)
+	mdsn := mFlags.Lookup(`dsn`)
+	sFlags.StringVar(&dsn, mdsn.Name, mdsn.DefValue, mdsn.Usage)
+	mdriver := mFlags.Lookup(`driver`)
+	sFlags.StringVar(&driver, mdriver.Name, mdriver.DefValue, mdriver.Usage)
+	mcreate := mFlags.Lookup(`create`)
+	sFlags.BoolVar(&create, mcreate.Name, false, mcreate.Usage)
+	sFlags.StringVar(&out, `out`, ``, `Path to the baseline migration file to write.`)
+	sFlags.Usage = func() {
+		say(squashTmpl, output, squashHelp())
+	}
+}
+
+func squashHelp() rx.Map {
+	return rx.Map{
+		squash:        sFlags.Name(),
+		`sdsn_help`:   sFlags.Lookup(`dsn`).Usage,
+		`driver_help`: sFlags.Lookup(`driver`).Usage,
+		`create_help`: sFlags.Lookup(`create`).Usage,
+		`out_help`:    sFlags.Lookup(`out`).Usage,
+	}
 }
 
 var (
@@ -77,18 +240,67 @@ Actions:
     Prints this message and exits.
 ${migrate}
 ${generate}
+${erd}
+${lint-templates}
+${anonymize}
+${squash}
 `
 	migrateTmpl = `  ${migrate}
   -sql_file  ${sql_file_help}
-  -dsn       ${mdsn_help}  
+  -dsn       ${mdsn_help}
+  -driver    ${driver_help}
+  -create    ${create_help}
   -direction ${direction_help}
   -log_level ${ll_help}
+  -suggest_down ${sd_help}
+  -wait      ${wait_help}
+  -progress  ${progress_help}
 `
 	generateTmpl = `  ${generate}
   -dsn       ${gdsn_help}
+  -driver    ${driver_help}
+  -create    ${create_help}
   -package   ${package_help}
   -log_level ${ll_help}
   -tables    ${tables_help}
+  -templates ${tpl_help}
+  -check     ${check_help}
+  -tests     ${tests_help}
+  -uuid      ${uuid_help}
+  -dto       ${dto_help}
+  -handlers  ${handlers_help}
+`
+	erdTmpl = `  ${erd}
+  -dsn       ${edsn_help}
+  -driver    ${driver_help}
+  -create    ${create_help}
+  -format    ${format_help}
+`
+	lintTmpl = `  ${lint-templates}
+    Renders all SQL templates for the driver and lets the database parse them.
+  -dsn       ${ldsn_help}
+  -driver    ${driver_help}
+  -create    ${create_help}
+  -table     ${table_help}
+`
+	anonTmpl = `  ${anonymize}
+    Overwrites personal data in the rows of a table and in the rows, which
+    reference them, in one transaction.
+  -dsn       ${adsn_help}
+  -driver    ${driver_help}
+  -create    ${create_help}
+  -table     ${atable_help}
+  -where     ${where_help}
+  -null      ${null_help}
+  -hash      ${hash_help}
+`
+	squashTmpl = `  ${squash}
+    Writes the schema as one baseline migration and marks all applied
+    migrations as superseded by it.
+  -dsn       ${sdsn_help}
+  -driver    ${driver_help}
+  -create    ${create_help}
+  -out       ${out_help}
 `
 )
 
@@ -108,21 +320,58 @@ func usage() {
 		migrate:          mFlags.Name(),
 		`sql_file_help`:  mFlags.Lookup(`sql_file`).Usage,
 		`mdsn_help`:      mFlags.Lookup(`dsn`).Usage,
+		`driver_help`:    mFlags.Lookup(`driver`).Usage,
+		`create_help`:    mFlags.Lookup(`create`).Usage,
 		`direction_help`: mFlags.Lookup(`direction`).Usage,
 		`ll_help`:        mFlags.Lookup(`log_level`).Usage,
+		`sd_help`:        mFlags.Lookup(`suggest_down`).Usage,
+		`wait_help`:      mFlags.Lookup(`wait`).Usage,
+		`progress_help`:  mFlags.Lookup(`progress`).Usage,
 	})
 	var gFlagsStr bytes.Buffer
 	say(generateTmpl, &gFlagsStr, rx.Map{
-		generate:       gFlags.Name(),
-		`package_help`: gFlags.Lookup(`package`).Usage,
-		`gdsn_help`:    gFlags.Lookup(`dsn`).Usage,
-		`ll_help`:      gFlags.Lookup(`log_level`).Usage,
-		`tables_help`:  gFlags.Lookup(`tables`).Usage,
+		generate:        gFlags.Name(),
+		`package_help`:  gFlags.Lookup(`package`).Usage,
+		`gdsn_help`:     gFlags.Lookup(`dsn`).Usage,
+		`driver_help`:   gFlags.Lookup(`driver`).Usage,
+		`create_help`:   gFlags.Lookup(`create`).Usage,
+		`ll_help`:       gFlags.Lookup(`log_level`).Usage,
+		`tables_help`:   gFlags.Lookup(`tables`).Usage,
+		`tpl_help`:      gFlags.Lookup(`templates`).Usage,
+		`check_help`:    gFlags.Lookup(`check`).Usage,
+		`tests_help`:    gFlags.Lookup(`tests`).Usage,
+		`uuid_help`:     gFlags.Lookup(`uuid`).Usage,
+		`dto_help`:      gFlags.Lookup(`dto`).Usage,
+		`handlers_help`: gFlags.Lookup(`handlers`).Usage,
+	})
+	var eFlagsStr bytes.Buffer
+	say(erdTmpl, &eFlagsStr, rx.Map{
+		erd:           eFlags.Name(),
+		`edsn_help`:   eFlags.Lookup(`dsn`).Usage,
+		`driver_help`: eFlags.Lookup(`driver`).Usage,
+		`create_help`: eFlags.Lookup(`create`).Usage,
+		`format_help`: eFlags.Lookup(`format`).Usage,
+	})
+	var lFlagsStr bytes.Buffer
+	say(lintTmpl, &lFlagsStr, rx.Map{
+		lint:          lFlags.Name(),
+		`ldsn_help`:   lFlags.Lookup(`dsn`).Usage,
+		`driver_help`: lFlags.Lookup(`driver`).Usage,
+		`create_help`: lFlags.Lookup(`create`).Usage,
+		`table_help`:  lFlags.Lookup(`table`).Usage,
 	})
+	var aFlagsStr bytes.Buffer
+	say(anonTmpl, &aFlagsStr, anonHelp())
+	var sFlagsStr bytes.Buffer
+	say(squashTmpl, &sFlagsStr, squashHelp())
 	say(usageTmpl, output, rx.Map{
 		`exe`:    os.Args[0],
 		migrate:  mFlagsStr.Bytes(),
 		generate: gFlagsStr.Bytes(),
+		erd:      eFlagsStr.Bytes(),
+		lint:     lFlagsStr.Bytes(),
+		anon:     aFlagsStr.Bytes(),
+		squash:   sFlagsStr.Bytes(),
 	})
 }
 
@@ -140,6 +389,14 @@ func run() int {
 		return runMigrate()
 	case generate:
 		return runGenerate()
+	case erd:
+		return runERD()
+	case lint:
+		return runLint()
+	case anon:
+		return runAnonymize()
+	case squash:
+		return runSquash()
 	default:
 		say("\nUknown action '${a}'!\n", output, rx.Map{`a`: action})
 		flag.Usage()
@@ -160,12 +417,23 @@ func runMigrate() int {
 		return 1
 	}
 	rx.Logger.SetLevel(ll)
+	rx.MigrateWait = wait
 
+	if suggestDown {
+		return runSuggestDown()
+	}
 	if dsn == `` || sqlFilePath == `` || direction == `` {
-		say("All flags beside 'log_level' are mandatory!\n", output, rx.Map{})
+		say("All flags beside 'log_level' and 'wait' are mandatory!\n", output, rx.Map{})
 		mFlags.Usage()
 		return 1
 	}
+	if !useDSN() {
+		return 1
+	}
+	rx.ProgressReporter = nil
+	if progress {
+		rx.ProgressReporter = rx.NewProgressWriter(output, time.Second)
+	}
 	if eh = rx.Migrate(sqlFilePath, dsn, direction); eh != nil {
 		rx.Logger.Errorf("\n=====\n%s", eh.Error())
 		return 2
@@ -173,6 +441,38 @@ func runMigrate() int {
 	return 0
 }
 
+// useDSN makes the driver from the flag `driver` the one, used by rx, if it
+// is registered and the DSN from the flag `dsn` is valid for it and points to
+// an existing database file or the flag `create` is set. Otherwise it says
+// what is wrong.
+func useDSN() bool {
+	if err := rx.ValidateDSN(driver, dsn); err != nil {
+		say("${err}\n", output, rx.Map{`err`: err.Error()})
+		return false
+	}
+	if err := rx.RequireDatabaseFile(driver, dsn); err != nil && !create {
+		say("${err}. Check the DSN or pass -create to create it.\n", output, rx.Map{`err`: err.Error()})
+		return false
+	}
+	rx.DriverName = driver
+	return true
+}
+
+func runSuggestDown() int {
+	if sqlFilePath == `` {
+		say("'sql_file' is mandatory!\n", output, rx.Map{})
+		mFlags.Usage()
+		return 1
+	}
+	downs, err := rx.SuggestDown(sqlFilePath)
+	if err != nil {
+		rx.Logger.Errorf("\n=====\n%s", err.Error())
+		return 2
+	}
+	say("${downs}", output, rx.Map{`downs`: downs})
+	return 0
+}
+
 func runGenerate() int {
 	eh := gFlags.Parse(os.Args[2:])
 	if eh != nil {
@@ -192,9 +492,139 @@ func runGenerate() int {
 		gFlags.Usage()
 		return 1
 	}
+	if !useDSN() {
+		return 1
+	}
+	if templatesDir != `` {
+		if eh = rx.LoadGeneratorTemplates(templatesDir); eh != nil {
+			rx.Logger.Errorf("\n=====\n%s!", eh.Error())
+			return 2
+		}
+	}
+	rx.GenerateTests = tests
+	rx.GenerateBinaryUUIDs = binaryUUIDs
+	rx.GenerateDTOs = dtos
+	rx.GenerateHandlers = handlers
+	if check {
+		return runCheck()
+	}
 	if eh = rx.Generate(dsn, packagePath, tables2structs); eh != nil {
 		rx.Logger.Errorf("\n=====\n%s!", eh.Error())
 		return 2
 	}
 	return 0
 }
+
+func runCheck() int {
+	diff, err := rx.CheckGenerated(dsn, packagePath, tables2structs)
+	if err != nil {
+		rx.Logger.Errorf("\n=====\n%s!", err.Error())
+		return 2
+	}
+	if diff != `` {
+		say("Generated files are stale:\n${diff}", output, rx.Map{`diff`: diff})
+		return 3
+	}
+	return 0
+}
+
+func runERD() int {
+	if eh := eFlags.Parse(os.Args[2:]); eh != nil {
+		return 1
+	}
+	if dsn == `` {
+		say("'dsn' is mandatory!\n", output, rx.Map{})
+		eFlags.Usage()
+		return 1
+	}
+	if !useDSN() {
+		return 1
+	}
+	// Make sure we connect to the given database.
+	rx.ResetDB()
+	rx.DSN = dsn
+	diagram, err := rx.ERD(rx.DB(), erdFormat)
+	if err != nil {
+		rx.Logger.Errorf("\n=====\n%s!", err.Error())
+		return 2
+	}
+	say("${erd}", output, rx.Map{erd: diagram})
+	return 0
+}
+
+func runLint() int {
+	if eh := lFlags.Parse(os.Args[2:]); eh != nil {
+		return 1
+	}
+	if dsn == `` || table == `` {
+		say("'dsn' and 'table' are mandatory!\n", output, rx.Map{})
+		lFlags.Usage()
+		return 1
+	}
+	if !useDSN() {
+		return 1
+	}
+	rx.ResetDB()
+	rx.DSN = dsn
+	if err := rx.ValidateTemplates(rx.DB(), table); err != nil {
+		rx.Logger.Errorf("\n=====\n%s", err.Error())
+		return 2
+	}
+	say("All templates are valid.\n", output, rx.Map{})
+	return 0
+}
+
+func runAnonymize() int {
+	if eh := aFlags.Parse(os.Args[2:]); eh != nil {
+		return 1
+	}
+	if dsn == `` || table == `` || where == `` {
+		say("'dsn', 'table' and 'where' are mandatory!\n", output, rx.Map{})
+		aFlags.Usage()
+		return 1
+	}
+	if !useDSN() {
+		return 1
+	}
+	rules := map[string]rx.Anonymizer{}
+	for column := range strings.SplitSeq(nullColumns, `,`) {
+		if column = strings.TrimSpace(column); column != `` {
+			rules[column] = rx.AnonymizeNull
+		}
+	}
+	for column := range strings.SplitSeq(hashColumns, `,`) {
+		if column = strings.TrimSpace(column); column != `` {
+			rules[column] = rx.AnonymizeHash
+		}
+	}
+	rx.ResetDB()
+	rx.DSN = dsn
+	n, err := rx.Anonymize(table, where, nil, rules)
+	if err != nil {
+		rx.Logger.Errorf("\n=====\n%s", err.Error())
+		return 2
+	}
+	say("Anonymized ${n} rows.\n", output, rx.Map{`n`: strconv.FormatInt(n, 10)})
+	return 0
+}
+
+func runSquash() int {
+	if eh := sFlags.Parse(os.Args[2:]); eh != nil {
+		return 1
+	}
+	if dsn == `` || out == `` {
+		say("'dsn' and 'out' are mandatory!\n", output, rx.Map{})
+		sFlags.Usage()
+		return 1
+	}
+	if !useDSN() {
+		return 1
+	}
+	version, err := rx.Squash(dsn, out)
+	if err != nil {
+		rx.Logger.Errorf("\n=====\n%s", err.Error())
+		return 2
+	}
+	say("Squashed into ${out} with version ${version}.\n", output, rx.Map{`out`: out, `version`: version})
+	return 0
+}
@@ -51,6 +51,11 @@ var cases = []struct {
 		code:   1,
 		output: "flag provided but not defined: -what",
 	},
+	{
+		args:   []string{`migrate`, `-wait`, `forever`},
+		code:   1,
+		output: "invalid value \"forever\" for flag -wait",
+	},
 	{
 		args:   []string{`migrate`, `-log_level`, `UNKNOWN`},
 		code:   1,
@@ -58,16 +63,54 @@ var cases = []struct {
 	},
 	{
 		args: []string{`migrate`, `-sql_file`, `rx/testdata/migrations_01.sql`,
-			`-dsn`, tempDBFile, `-direction`, `left`},
+			`-dsn`, tempDBFile, `-direction`, `left`, `-create`},
 		code:   2,
 		output: "direction can be only",
 	},
 	{
 		args: []string{`migrate`, `-sql_file`, `rx/testdata/migrations_01.sql`,
 			`-dsn`, tempDBFile, `-direction`, `up`},
+		code:   1,
+		output: "database file does not exist: " + tempDBFile + ". Check the DSN or pass -create to create it.\n",
+	},
+	{
+		args: []string{`migrate`, `-sql_file`, `rx/testdata/migrations_01.sql`,
+			`-dsn`, tempDBFile, `-direction`, `up`, `-wait`, `1s`, `-create`},
 		code:   0,
 		output: "Applying 201804092200 up",
 	},
+	{
+		args: []string{`migrate`, `-sql_file`, `rx/testdata/migrations_01.sql`,
+			`-dsn`, `postgres://localhost/app`, `-direction`, `up`, `-driver`, `pgx`},
+		code:   1,
+		output: "driver pgx is not registered",
+	},
+	{
+		args:   []string{`generate`, `-dsn`, `postgres://localhost/app`, `-package`, `rx/` + os.Getenv("EXAMPLE_MODEL")},
+		code:   1,
+		output: "invalid DSN 'postgres://localhost/app' for driver sqlite3. Expected: ",
+	},
+	{
+		args: []string{`migrate`, `-sql_file`, `rx/testdata/migrations_01.sql`,
+			`-dsn`, tempDBFile, `-direction`, `up`, `-progress`},
+		code:   0,
+		output: "MIGRATE rx/testdata/migrations_01.sql: 0/0 statements (0 statements/s)\n",
+	},
+	{
+		args:   []string{`migrate`, `-suggest_down`},
+		code:   1,
+		output: "'sql_file' is mandatory!",
+	},
+	{
+		args:   []string{`migrate`, `-suggest_down`, `-sql_file`, `rx/testdata/no_such.sql`},
+		code:   2,
+		output: "no such file or directory",
+	},
+	{
+		args:   []string{`migrate`, `-suggest_down`, `-sql_file`, `rx/testdata/migrations_02.sql`},
+		code:   0,
+		output: "-- 202510100900 down\nALTER TABLE tags DROP COLUMN description;",
+	},
 	{
 		args:   []string{`generate`},
 		code:   1,
@@ -88,6 +131,11 @@ var cases = []struct {
 		code:   1,
 		output: "No such log_level: UNKNOWN.\n",
 	},
+	{
+		args:   []string{`generate`, `-dsn`, tempDBFile + `.typo`, `-package`, `rx/` + os.Getenv("EXAMPLE_MODEL")},
+		code:   1,
+		output: "database file does not exist: " + tempDBFile + ".typo",
+	},
 	{
 		args:   []string{`generate`, `-dsn`, tempDBFile, `-package`, `rx/` + os.Getenv("EXAMPLE_MODEL")},
 		code:   0,
@@ -97,6 +145,39 @@ var cases = []struct {
 			require.NoErrorf(t, err, `Unexpected error: %+v`, err)
 		},
 	},
+	{
+		args:   []string{`generate`, `-dsn`, `file::memory:?cache=shared`, `-package`, `rx/` + os.Getenv("EXAMPLE_MODEL")},
+		code:   2,
+		output: "no tables to generate structures for in file::memory:?cache=shared",
+	},
+	{
+		args: []string{`generate`, `-dsn`, tempDBFile, `-package`, `rx/` + os.Getenv("EXAMPLE_MODEL"),
+			`-templates`, `rx/testdata/no_such`},
+		code:   0,
+		output: "_tables.go...",
+	},
+	{
+		args:   []string{`generate`, `-dsn`, tempDBFile, `-package`, `rx/` + os.Getenv("EXAMPLE_MODEL"), `-check`},
+		code:   0,
+		output: "",
+	},
+	{
+		args: []string{`generate`, `-dsn`, tempDBFile, `-package`, `rx/` + os.Getenv("EXAMPLE_MODEL"),
+			`-check`, `-tables`, `users`},
+		code:   3,
+		output: "Generated files are stale:\n---",
+	},
+	{
+		args: []string{`generate`, `-dsn`, tempDBFile, `-package`, `rx/` + os.Getenv("EXAMPLE_MODEL"),
+			`-tests`},
+		code:   0,
+		output: "_tables_test.go...",
+	},
+	{
+		args:   []string{`generate`, `-dsn`, tempDBFile, `-package`, os.Getenv("EXAMPLE_MODEL"), `-check`},
+		code:   2,
+		output: "The directory must exist already!",
+	},
 	{
 		args:   []string{`generate`, `-dsn`, tempDBFile, `-package`, os.Getenv("EXAMPLE_MODEL")},
 		code:   2,
@@ -106,6 +187,72 @@ var cases = []struct {
 			require.NoErrorf(t, err, `Unexpected error: %+v`, err)
 		},
 	},
+	{
+		args:   []string{`erd`},
+		code:   1,
+		output: "'dsn' is mandatory!\n  erd",
+	},
+	{
+		args:   []string{`erd`, `-what`},
+		code:   1,
+		output: "flag provided but not defined: -what",
+	},
+	{
+		args:   []string{`erd`, `-dsn`, tempDBFile, `-format`, `png`},
+		code:   2,
+		output: "format can be only",
+	},
+	{
+		args:   []string{`erd`, `-dsn`, tempDBFile, `-format`, `dot`},
+		code:   0,
+		output: "digraph erd {\n",
+	},
+	{
+		args:   []string{`erd`, `-dsn`, tempDBFile},
+		code:   0,
+		output: "erDiagram\n",
+	},
+	{
+		args:   []string{`lint-templates`, `-dsn`, tempDBFile},
+		code:   1,
+		output: "'dsn' and 'table' are mandatory!\n  lint-templates",
+	},
+	{
+		args:   []string{`lint-templates`, `-dsn`, tempDBFile, `-table`, `no_such`},
+		code:   2,
+		output: "",
+	},
+	{
+		args:   []string{`lint-templates`, `-dsn`, tempDBFile, `-table`, `users`},
+		code:   0,
+		output: "All templates are valid.\n",
+	},
+	{
+		args:   []string{`anonymize`, `-dsn`, tempDBFile, `-table`, `users`},
+		code:   1,
+		output: "'dsn', 'table' and 'where' are mandatory!\n  anonymize",
+	},
+	{
+		args: []string{`anonymize`, `-dsn`, tempDBFile, `-table`, `users`, `-where`, `id = 2`,
+			`-null`, `users.description`, `-hash`, `email`},
+		code:   0,
+		output: "Anonymized 1 rows.\n",
+	},
+	{
+		args:   []string{`anonymize`, `-dsn`, tempDBFile, `-table`, `users`, `-where`, `no_such = 2`, `-hash`, `email`},
+		code:   2,
+		output: "no such column: no_such",
+	},
+	{
+		args:   []string{`squash`, `-dsn`, tempDBFile},
+		code:   1,
+		output: "'dsn' and 'out' are mandatory!\n  squash",
+	},
+	{
+		args:   []string{`squash`, `-dsn`, tempDBFile, `-out`, tempDBFile + `.baseline.sql`},
+		code:   0,
+		output: "Squashed into " + tempDBFile + ".baseline.sql with version ",
+	},
 	{
 		args:   []string{`alabalanica`},
 		code:   1,
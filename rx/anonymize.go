@@ -0,0 +1,215 @@
+package rx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Anonymizer returns the value, which replaces `value` in `column`. nil sets
+// the column to NULL. Text is passed as string.
+type Anonymizer func(column string, value any) any
+
+// AnonymizeNull sets columns to NULL.
+func AnonymizeNull(string, any) any { return nil }
+
+// AnonymizeWith returns an [Anonymizer], which sets columns to `value`.
+func AnonymizeWith(value any) Anonymizer {
+	return func(string, any) any { return value }
+}
+
+// AnonymizeHash replaces values with their SHA-256 sums as hexadecimal
+// strings, so unique columns stay unique. NULL stays NULL.
+func AnonymizeHash(_ string, value any) any {
+	if value == nil {
+		return nil
+	}
+	sum := sha256.Sum256(fmt.Append(nil, value))
+	return hex.EncodeToString(sum[:])
+}
+
+// piiColumns keeps the columns with the tag option `pii` by table.
+var piiColumns sync.Map
+
+/*
+RegisterPII registers the columns of R with the tag option `pii` as personal
+data in its table for [Anonymize].
+
+	type Users struct {
+		Email string `rx:"email,pii"`
+		...
+	}
+
+	rx.RegisterPII[Users]()
+*/
+func RegisterPII[R Rowx]() {
+	if reflect.TypeFor[R]().Kind() != reflect.Struct {
+		return
+	}
+	var columns []string
+	for _, fi := range fieldsMap[R]().Index {
+		if hasOption(fi, `pii`) {
+			columns = append(columns, fi.Path)
+		}
+	}
+	piiColumns.Store(NewRx[R]().Table(), columns)
+}
+
+/*
+Anonymize overwrites personal data in the rows of `table`, matching `where`,
+and in the rows, which reference them by foreign keys, recursively, like
+[Rx.DeleteCascade] finds them. `rules` maps columns to [Anonymizer]s. A key is
+either a column name, used in every table with such a column, or
+`table.column`. The columns, registered with [RegisterPII], for which there is
+no rule, are set to NULL. Primary key columns are not changed and every table
+with columns to anonymize must have a primary key. `where` is mandatory. All
+rows are updated in one transaction on [DB]. Returns the number of updated
+rows. If any of the tables has a [RowPolicy], it returns [ErrRowPolicy] and
+changes nothing.
+
+	n, err := rx.Anonymize(`users`, `id = :id`, rx.Map{`id`: 3}, map[string]rx.Anonymizer{
+		`login_name`: rx.AnonymizeHash,
+		`email`:      rx.AnonymizeWith(`erased@example.com`),
+	})
+*/
+func Anonymize(table, where string, bindData Map, rules map[string]Anonymizer) (n int64, err error) {
+	if strings.TrimSpace(where) == `` {
+		return 0, errors.New(`anonymize: where is mandatory`)
+	}
+	if err := checkWhere(where); err != nil {
+		return 0, err
+	}
+	if bindData == nil {
+		bindData = Map{}
+	}
+	db := DB()
+	fks, err := collectForeignKeys(db)
+	if err != nil {
+		return 0, err
+	}
+	info, err := collectTableColumnInfo(db, ``)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	// The rollback will be ignored if the tx has been committed already.
+	defer func() { _ = tx.Rollback() }()
+	all := func(ForeignKey) bool { return true }
+	stashes := dependents(table, ifWhere(where), fks, []string{table}, all)
+	for _, stash := range stashes {
+		if _, ok := rowPolicies.Load(stash[`table`]); ok {
+			return 0, fmt.Errorf(`%w: anonymize %s`, ErrRowPolicy, stash[`table`])
+		}
+	}
+	for _, stash := range stashes {
+		updated, err := anonymizeRows(tx, info, stash, bindData, rules)
+		if err != nil {
+			return 0, err
+		}
+		n += updated
+	}
+	return n, tx.Commit()
+}
+
+// anonymizeRows anonymizes the rows of the table in `stash`, matching its
+// `WHERE`, and returns their number. `info` has the columns of all tables.
+func anonymizeRows(tx *sqlx.Tx, info []columnInfo, stash, bindData Map, rules map[string]Anonymizer) (int64, error) {
+	table := stash[`table`].(string)
+	pii, _ := piiColumns.Load(table)
+	var keys, columns []string
+	anonymizers := map[string]Anonymizer{}
+	for _, c := range info {
+		if c.TableName != table {
+			continue
+		}
+		if c.PK > 0 {
+			keys = append(keys, c.CName)
+			continue
+		}
+		a := rules[table+`.`+c.CName]
+		if a == nil {
+			a = rules[c.CName]
+		}
+		if a == nil && pii != nil && slices.Contains(pii.([]string), c.CName) {
+			a = AnonymizeNull
+		}
+		if a != nil {
+			columns = append(columns, c.CName)
+			anonymizers[c.CName] = a
+		}
+	}
+	if len(columns) == 0 {
+		return 0, nil
+	}
+	if len(keys) == 0 {
+		return 0, fmt.Errorf(`anonymize %s: a primary key is needed`, table)
+	}
+	rows, err := anonymizedRows(tx, sprintf(`SELECT %s FROM %s %s`,
+		strings.Join(slices.Concat(keys, columns), `,`), table, stash[`WHERE`]), bindData)
+	if err != nil {
+		return 0, err
+	}
+	conditions := make([]string, len(keys))
+	for i, k := range keys {
+		conditions[i] = sprintf(`%s = :%[1]s`, k)
+	}
+	set := sqlForSET(Logger, columns)
+	update, err := RenderSQLTemplateE(dialectKey(`UPDATE`, tx.DriverName()), Map{
+		`table`:       table,
+		`SET`:         set,
+		`assignments`: strings.TrimPrefix(set, `SET `),
+		`WHERE`:       `WHERE ` + strings.Join(conditions, ` AND `),
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, row := range rows {
+		for _, c := range columns {
+			value := row[c]
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			row[c] = anonymizers[c](c, value)
+		}
+		if _, err := tx.NamedExec(update, row); err != nil {
+			return 0, dbError(err)
+		}
+	}
+	return int64(len(rows)), nil
+}
+
+// anonymizedRows returns the rows, selected by `query`, before they are
+// changed.
+func anonymizedRows(tx *sqlx.Tx, query string, bindData Map) ([]Map, error) {
+	q, args, err := sqlx.Named(query, bindData)
+	if err != nil {
+		return nil, err
+	}
+	if q, args, err = sqlx.In(q, args...); err != nil {
+		return nil, err
+	}
+	rs, err := tx.Queryx(tx.Rebind(q), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rs.Close() }()
+	var rows []Map
+	for rs.Next() {
+		row := Map{}
+		if err := rs.MapScan(row); err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, rs.Err()
+}
@@ -0,0 +1,126 @@
+package rx
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// CheckpointsTable is where [Batch] keeps the last processed primary key of
+// interrupted jobs.
+const CheckpointsTable = `rx_checkpoints`
+
+/*
+Batch iterates the rows of the table of `model`, matching `where`, in primary
+key order, `batchSize` rows at a time. Every chunk is selected and passed to
+`fn` in its own transaction, together with a clone of `model`, which executes
+its queries in this transaction. After `fn` returns nil, the primary key of the
+last row is recorded in [CheckpointsTable] in the same transaction, which is
+then committed. If `fn` returns an error, the transaction is rolled back and
+the error is returned. Calling Batch again with the same table and `where`
+resumes after the last committed chunk. When all rows are processed, the
+checkpoint is removed.
+
+The table must have one primary key column. `where` may contain named bind
+parameters from `bindData` and must not contain ORDER BY. The bind parameter
+`rx_batch_after` is reserved.
+
+	err := rx.Batch(rx.NewRx[Users](), `email IS NULL`, nil, 500,
+		func(m rx.SqlxModel[Users], rows []Users) error {
+			for i := range rows {
+				rows[i].Email = guessEmail(rows[i])
+			}
+			_, err := m.SetData(rows).Update([]string{`email`}, `id = :id`)
+			return err
+		})
+*/
+func Batch[R Rowx](model SqlxModel[R], where string, bindData Map, batchSize int,
+	fn func(m SqlxModel[R], rows []R) error) error {
+	pk, ok := model.(interface{ pkColumns() []string })
+	if !ok || len(pk.pkColumns()) != 1 {
+		return fmt.Errorf(`batch on %s: exactly one primary key column is needed`, model.Table())
+	}
+	if batchSize < 1 {
+		return fmt.Errorf(`batch on %s: batchSize must be positive`, model.Table())
+	}
+	key := pk.pkColumns()[0]
+	job := strings.TrimSpace(model.Table() + ` ` + where)
+	p := startProgress(`BATCH `+job, `rows`, -1)
+	defer p.finish()
+	for {
+		done, err := batchChunk(model, job, key, where, bindData, batchSize, fn, p)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// batchChunk processes the next chunk of a [Batch] job in a transaction,
+// reports its rows to `p` and reports if it was the last one.
+func batchChunk[R Rowx](model SqlxModel[R], job, key, where string, bindData Map, batchSize int,
+	fn func(m SqlxModel[R], rows []R) error, p *progress) (done bool, err error) {
+	m := model.Clone()
+	tx := m.Tx()
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	create, err := RenderSQLTemplateE(`CREATE_CHECKPOINTS_TABLE`, Map{`table`: CheckpointsTable})
+	if err != nil {
+		return false, err
+	}
+	if _, err = tx.Exec(create); err != nil {
+		return false, err
+	}
+	var after string
+	err = tx.Get(&after, tx.Rebind(sprintf(`SELECT last_key FROM %s WHERE job = ?`, CheckpointsTable)), job)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+	bind := maps.Clone(bindData)
+	if bind == nil {
+		bind = Map{}
+	}
+	condition := where
+	if err == nil {
+		bind[`rx_batch_after`] = after
+		condition = sprintf(`%s > :rx_batch_after`, key)
+		if where != `` {
+			condition = sprintf(`(%s) AND %s`, where, condition)
+		}
+	}
+	if condition == `` {
+		condition = `1=1`
+	}
+	rows, err := m.Select(condition+` ORDER BY `+key, bind, batchSize, 0)
+	if err != nil {
+		return false, err
+	}
+	if len(rows) > 0 {
+		if err = fn(m, rows); err != nil {
+			return false, err
+		}
+	}
+	if _, err = tx.Exec(tx.Rebind(sprintf(`DELETE FROM %s WHERE job = ?`, CheckpointsTable)), job); err != nil {
+		return false, err
+	}
+	if done = len(rows) < batchSize; !done {
+		last, err := fieldValue(&rows[len(rows)-1], key)
+		if err != nil {
+			return false, err
+		}
+		_, err = tx.Exec(tx.Rebind(sprintf(`INSERT INTO %s (job, last_key) VALUES (?, ?)`, CheckpointsTable)),
+			job, fmt.Sprint(last))
+		if err != nil {
+			return false, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+	p.add(int64(len(rows)))
+	return done, nil
+}
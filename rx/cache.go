@@ -0,0 +1,200 @@
+package rx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+/*
+queryParts are the parts of SELECT and INSERT queries, which depend only on the
+type of the rows, the table, the driver and the columns, used by an instance of
+Rx. They are rendered once and reused by all instances with the same type,
+table, driver and columns, so the queries of high-QPS services do not allocate
+them on every call.
+*/
+type queryParts struct {
+	// columns for which the parts were rendered.
+	columns []string
+	// selectColumns is the list of columns for SELECT.
+	selectColumns string
+	// insertColumns are the columns, which values are inserted.
+	insertColumns []string
+	// insertList and placeholders are the list of insertColumns and their
+	// named bind parameters for INSERT.
+	insertList, placeholders string
+	// orderBy is the default `ORDER BY` of SELECT queries, declared with the
+	// tag option `defaultorder`, and orderErr - the error in the declaration.
+	orderBy  string
+	orderErr error
+	// cached is true, if the parts are kept in partsCache.
+	cached bool
+	// find keeps the prepared statements of [Rx.Find] by connection.
+	find sync.Map
+}
+
+// preparedFind is a prepared statement of [Rx.Find] and its query.
+type preparedFind struct {
+	stmt  *sqlx.Stmt
+	query string
+}
+
+// partsKey is the key of the cached queryParts. The name of the driver is in
+// it, so the same type and table, used with different databases, get their own
+// parts.
+type partsKey struct {
+	typ    reflect.Type
+	table  string
+	driver string
+}
+
+// partsVariants is the number of different sets of columns per partsKey,
+// for which queryParts are cached. They are rendered on every call for more.
+const partsVariants = 8
+
+var (
+	// partsCache keeps the cached queryParts. A map with a mutex is used
+	// instead of sync.Map, because converting a partsKey to a key of sync.Map
+	// allocates.
+	partsCache = struct {
+		sync.RWMutex
+		sets map[partsKey][]*queryParts
+	}{sets: map[partsKey][]*queryParts{}}
+	// columnsCache keeps the columns of types, which do not implement
+	// `Columns() []string`, by type.
+	columnsCache sync.Map
+	// bufPool keeps buffers for rendering queryParts, which are not cached.
+	bufPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+)
+
+// parts returns the cached queryParts for this instance or renders and caches
+// them.
+func (m *Rx[R]) parts() *queryParts {
+	key, columns := partsKey{reflect.TypeFor[R](), m.Table(), m.tX().DriverName()}, m.Columns()
+	partsCache.RLock()
+	for _, p := range partsCache.sets[key] {
+		if slices.Equal(p.columns, columns) {
+			partsCache.RUnlock()
+			return p
+		}
+	}
+	partsCache.RUnlock()
+	p := m.renderParts()
+	partsCache.Lock()
+	defer partsCache.Unlock()
+	if len(partsCache.sets[key]) < partsVariants {
+		p.cached = true
+		partsCache.sets[key] = append(partsCache.sets[key], p)
+	}
+	return p
+}
+
+func (m *Rx[R]) renderParts() *queryParts {
+	p := &queryParts{columns: slices.Clone(m.Columns()), insertColumns: m.insertColumns()}
+	p.selectColumns = m.selectColumns()
+	p.insertList = joinColumns(``, p.insertColumns, ``)
+	p.placeholders = joinColumns(`(`, p.insertColumns, `)`)
+	p.orderBy, p.orderErr = defaultOrder[R]()
+	return p
+}
+
+// joinColumns joins `columns` with commas between `open` and `close`. If
+// `open` is not empty, every column is prefixed with a colon, so it becomes a
+// named bind parameter.
+func joinColumns(open string, columns []string, close string) string {
+	buf := bufPool.Get().(*bytes.Buffer)
+	defer bufPool.Put(buf)
+	buf.Reset()
+	buf.WriteString(open)
+	for i, col := range columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if open != `` {
+			buf.WriteByte(':')
+		}
+		buf.WriteString(col)
+	}
+	buf.WriteString(close)
+	return buf.String()
+}
+
+/*
+findStmt returns the prepared statement of [Rx.Find] for `db`. It selects the
+row from `table` by the primary key `columns` with positional bind parameters.
+*/
+func (p *queryParts) findStmt(ctx context.Context, db *sqlx.DB, columns []string, table string) (*preparedFind, error) {
+	if find, ok := p.find.Load(db); ok {
+		return find.(*preparedFind), nil
+	}
+	where := make([]string, len(columns))
+	for i, c := range columns {
+		where[i] = c + ` = ?`
+	}
+	query := db.Rebind(sprintf(`SELECT %s FROM %s WHERE %s`, p.selectColumns, table, strings.Join(where, ` AND `)))
+	stmt, err := db.PreparexContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	find, loaded := p.find.LoadOrStore(db, &preparedFind{stmt: stmt, query: query})
+	if loaded {
+		_ = stmt.Close()
+	}
+	return find.(*preparedFind), nil
+}
+
+/*
+Warm computes at startup the metadata of the types of `models` - fields,
+columns and the parts of queries, and prepares the statements of [Rx.Find], so
+the first queries in the request path are not slower than the rest. `models`
+are instances, returned by [NewRx] or [NewRxWith], so tables and columns, set
+explicitly, are warmed up too. Returns the errors of preparing the statements,
+joined, e.g. if a table does not exist yet.
+
+	err := rx.Warm(rx.NewRx[Users](), rx.NewRx[Groups](), rx.NewRxWith[Users](rx.WithTable(`admins`)))
+*/
+func Warm(models ...any) error {
+	var errs []error
+	for _, model := range models {
+		w, ok := model.(interface{ warm() error })
+		if !ok {
+			errs = append(errs, fmt.Errorf(`%T is not a model, returned by rx.NewRx`, model))
+			continue
+		}
+		errs = append(errs, w.warm())
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Rx[R]) warm() error {
+	columns := m.pkColumns()
+	db, parts, prepared := m.preparedFind()
+	if len(columns) == 0 || !prepared {
+		return nil
+	}
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	_, err := parts.findStmt(ctx, db, columns, m.Table())
+	return err
+}
+
+// closeStmts closes and forgets the prepared statements for `db`, before it
+// is closed.
+func closeStmts(db *sqlx.DB) {
+	partsCache.RLock()
+	defer partsCache.RUnlock()
+	for _, set := range partsCache.sets {
+		for _, p := range set {
+			if find, ok := p.find.LoadAndDelete(db); ok {
+				_ = find.(*preparedFind).stmt.Close()
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package rx
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+/*
+DeleteCascade deletes records like [Rx.Delete], but first deletes the rows,
+which depend on them, for databases or schemas without ON DELETE CASCADE. The
+foreign keys are found like in [ForeignKeysOf]. Rows of child tables are
+deleted before the rows of their parent tables, recursively, with conditions
+like `author_id IN (SELECT id FROM users WHERE ...)`. Foreign keys with ON
+DELETE SET NULL or SET DEFAULT and references back to a table on the current
+path (like users.changed_by to users) are not followed. The columns of
+composite foreign keys are matched separately. If no transaction was set with
+[Rx.WithTx], all statements are executed in a new transaction. Returns the
+result of the deletion from the table of this instance. The [RowPolicy] of the
+table of this instance is applied. If any of the dependent tables has a
+[RowPolicy], it returns [ErrRowPolicy] and deletes nothing.
+
+	_, err := rx.NewRx[Users]().DeleteCascade(`id = :id`, rx.Map{`id`: 3})
+*/
+func (m *Rx[R]) DeleteCascade(where string, bindData any) (r sql.Result, err error) {
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`DELETE_CASCADE`, time.Now(), &err)
+	if err := m.checkWrite(); err != nil {
+		return nil, err
+	}
+	if err := checkWhere(where); err != nil {
+		return nil, err
+	}
+	if bindData == nil {
+		bindData = map[string]any{}
+	}
+	ex := m.tX()
+	fks, err := collectForeignKeys(ex)
+	if err != nil {
+		return nil, err
+	}
+	condition := m.where(`DELETE`, where)
+	policyBind := m.policyBind
+	// Rows, which are set to NULL or to the default on deletion of the
+	// referenced rows, do not depend on them.
+	deleted := func(fk ForeignKey) bool { return fk.OnDelete != `SET NULL` && fk.OnDelete != `SET DEFAULT` }
+	stashes := dependents(m.Table(), condition, fks, []string{m.Table()}, deleted)
+	for _, stash := range stashes[:len(stashes)-1] {
+		if _, ok := rowPolicies.Load(stash[`table`]); ok {
+			return nil, fmt.Errorf(`%w: delete cascade %s`, ErrRowPolicy, stash[`table`])
+		}
+	}
+	if db, ok := ex.(*sqlx.DB); ok {
+		tx, err := db.BeginTxx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		// The rollback will be ignored if the tx has been committed already.
+		defer func() { _ = tx.Rollback() }()
+		ex = tx
+	}
+	for _, stash := range stashes {
+		query, err := m.render(`DELETE`, stash)
+		if err != nil {
+			return nil, err
+		}
+		m.policyBind = policyBind
+		q, args, err := m.namedInRebind(query, bindData)
+		if err != nil {
+			return nil, err
+		}
+		if r, err = ex.ExecContext(ctx, q, args...); err != nil {
+			return nil, dbError(err)
+		}
+	}
+	if tx, ok := ex.(*sqlx.Tx); ok && tx != m.queryer {
+		if err = tx.Commit(); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+/*
+dependents returns stashes with `table` and `WHERE` for the rows of `table`,
+matching `where`, preceded by the ones for the rows, which reference them
+recursively by the foreign keys, for which `follow` returns true. `path`
+contains the tables from the root to `table`.
+*/
+func dependents(table, where string, fks []ForeignKey, path []string, follow func(ForeignKey) bool) []Map {
+	var stashes []Map
+	for _, fk := range fks {
+		if fk.RefTable != table || slices.Contains(path, fk.Table) || !follow(fk) {
+			continue
+		}
+		childWhere := sprintf(`WHERE %s IN (SELECT %s FROM %s %s)`, fk.Column, fk.RefColumn, table, where)
+		stashes = append(stashes, dependents(fk.Table, childWhere, fks, slices.Concat(path, []string{fk.Table}), follow)...)
+	}
+	return append(stashes, Map{`table`: table, `WHERE`: where})
+}
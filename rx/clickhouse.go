@@ -0,0 +1,74 @@
+package rx
+
+import (
+	"regexp"
+	"strings"
+)
+
+/*
+ClickHouse (driver `clickhouse`) is supported for append-heavy workloads like
+logs and ETL. [Rx.Insert] executes one prepared statement for all rows in a
+transaction, which the driver sends to the server as a single batch. There are
+no transactional UPDATE and DELETE in ClickHouse, so [Rx.Update] and
+[Rx.Delete] are emulated via the asynchronous mutations `ALTER TABLE ...
+UPDATE` and `ALTER TABLE ... DELETE`, which require a WHERE clause. RETURNING
+is not supported. See the templates with suffix `_clickhouse` in
+[QueryTemplates].
+*/
+
+// batchInsertDrivers are the drivers, for which [Rx.Insert] executes a
+// prepared statement for every row in a transaction instead of one multi-row
+// INSERT.
+var batchInsertDrivers = []string{`clickhouse`}
+
+// clickHouseTypes maps ClickHouse types to the types, known to
+// sql2GoTypeAndTag.
+var clickHouseTypes = map[string]string{
+	`Bool`:        `boolean`,
+	`Int8`:        `tinyint`,
+	`Int16`:       `smallint`,
+	`Int32`:       `int4`,
+	`Int64`:       `bigint`,
+	`Int128`:      `hugeint`,
+	`Int256`:      `hugeint`,
+	`UInt8`:       `utinyint`,
+	`UInt16`:      `usmallint`,
+	`UInt32`:      `uinteger`,
+	`UInt64`:      `ubigint`,
+	`UInt128`:     `uhugeint`,
+	`UInt256`:     `uhugeint`,
+	`Float32`:     `real`,
+	`Float64`:     `double`,
+	`Decimal`:     `decimal`,
+	`String`:      `text`,
+	`FixedString`: `text`,
+	`UUID`:        `uuid`,
+	`Date`:        `date`,
+	`Date32`:      `date`,
+	`DateTime`:    `timestamp`,
+	`DateTime64`:  `timestamp`,
+	`Enum8`:       `enum`,
+	`Enum16`:      `enum`,
+}
+
+var chWrapper = regexp.MustCompile(`^(?:Nullable|LowCardinality)\((.+)\)$`)
+
+/*
+clickHouseType returns the type of a column, reported by ClickHouse, as one of
+the types, known to sql2GoTypeAndTag. Nullable is reflected in the column
+information already, so it and LowCardinality are stripped. Array(T) becomes
+a list.
+*/
+func clickHouseType(cType string) string {
+	for chWrapper.MatchString(cType) {
+		cType = chWrapper.FindStringSubmatch(cType)[1]
+	}
+	if inner, ok := strings.CutPrefix(cType, `Array(`); ok {
+		return clickHouseType(strings.TrimSuffix(inner, `)`)) + `[]`
+	}
+	name, _, _ := strings.Cut(cType, `(`)
+	if t, ok := clickHouseTypes[name]; ok {
+		return t
+	}
+	return cType
+}
@@ -0,0 +1,101 @@
+package rx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// shutdown keeps the state, needed by [Close].
+var shutdown struct {
+	sync.Mutex
+	// inFlight is the number of queries, which are executed now. It is
+	// counted atomically, so the queries do not wait for each other.
+	inFlight atomic.Int64
+	// closing is true, while Close waits for the queries in flight.
+	closing atomic.Bool
+	dbs     []*sqlx.DB
+	onClose []func(context.Context)
+}
+
+// idle receives a value, when the last query in flight finishes, while Close
+// waits.
+var idle = make(chan struct{}, 1)
+
+// queryStarted and queryDone count the queries in flight.
+func queryStarted() {
+	shutdown.inFlight.Add(1)
+}
+
+func queryDone() {
+	if shutdown.inFlight.Add(-1) == 0 && shutdown.closing.Load() {
+		select {
+		case idle <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// OnClose adds a hook, called by [Close] after the queries in flight finished
+// and before the connections are closed.
+func OnClose(hook func(ctx context.Context)) {
+	shutdown.Lock()
+	defer shutdown.Unlock()
+	shutdown.onClose = append(shutdown.onClose, hook)
+}
+
+// RegisterDB adds `db` to the handles, closed by [Close]. The handle, returned
+// by [DB], is always closed.
+func RegisterDB(db *sqlx.DB) {
+	shutdown.Lock()
+	defer shutdown.Unlock()
+	shutdown.dbs = append(shutdown.dbs, db)
+}
+
+/*
+Close shuts rx down gracefully. It waits for the queries, executed by models,
+to finish, until `ctx` is done, runs the hooks, added by [OnClose] and closes
+the handles, added by [RegisterDB], and the one, returned by [DB]. Errors,
+including the error of `ctx`, if the queries did not finish in time, are
+joined.
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := rx.Close(ctx)
+*/
+func Close(ctx context.Context) error {
+	shutdown.Lock()
+	hooks, dbs := shutdown.onClose, shutdown.dbs
+	shutdown.onClose, shutdown.dbs = nil, nil
+	shutdown.Unlock()
+
+	var errs []error
+	shutdown.closing.Store(true)
+wait:
+	for shutdown.inFlight.Load() > 0 {
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			errs = append(errs, ctx.Err())
+			break wait
+		}
+	}
+	shutdown.closing.Store(false)
+	for _, hook := range hooks {
+		hook(ctx)
+	}
+	if singleDB != nil {
+		dbs = append(dbs, singleDB)
+		singleDB = nil
+	}
+	for _, db := range dbs {
+		closeStmts(db)
+		if err := db.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,45 @@
+package rx
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// ChangedValue is the old and the new value of a column, reported by [Diff].
+type ChangedValue struct {
+	Old any
+	New any
+}
+
+/*
+Diff compares two instances of R column by column and returns the columns,
+which values differ, with their values in `a` as Old and in `b` as New. The
+columns are the same as the ones, returned by [Rx.Columns] for a type, which
+does not implement the method Columns. Values are compared with
+[reflect.DeepEqual]. An empty map means that the rows are equal. It is the
+building block for dirty tracking, audit logs and partial updates:
+
+	changes := rx.Diff(before, after)
+	_, err := rx.NewRx[Users](after).Update(slices.Collect(maps.Keys(changes)), `id = :id`)
+*/
+func Diff[R Rowx](a, b R) map[string]ChangedValue {
+	changes := map[string]ChangedValue{}
+	if reflect.TypeFor[R]().Kind() != reflect.Struct {
+		return changes
+	}
+	va, vb := reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem()
+	for _, fi := range fieldsMap[R]().Index {
+		// The same fields are skipped as in Columns.
+		if fi.Name == `rx` || hasOption(fi, `-`) || strings.Contains(fi.Path, `.`) {
+			continue
+		}
+		old := reflectx.FieldByIndexesReadOnly(va, fi.Index).Interface()
+		value := reflectx.FieldByIndexesReadOnly(vb, fi.Index).Interface()
+		if !reflect.DeepEqual(old, value) {
+			changes[fi.Path] = ChangedValue{Old: old, New: value}
+		}
+	}
+	return changes
+}
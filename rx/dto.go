@@ -0,0 +1,90 @@
+package rx
+
+import (
+	"database/sql"
+	"strings"
+)
+
+var dtoHeader = `package ${package}
+
+/*
+This file will be regenerated each time you run [rx.Generate] with DTOs. The
+structures in it represent the rows of the tables in API payloads. Null values
+are nil pointers, so they are encoded as JSON null.
+*/
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/kberov/rowx/rx"
+)
+`
+
+var dtoTemplate = `
+// ${TableName}DTO is the representation of ${TableName} in API payloads.
+type ${TableName}DTO struct {
+${fields}
+}
+
+// DTO converts u to ${TableName}DTO.
+func (u *${TableName}) DTO() ${TableName}DTO {
+	return ${TableName}DTO{${to_dto}
+	}
+}
+
+// Row converts d to ${TableName}.
+func (d *${TableName}DTO) Row() ${TableName} {
+	return ${TableName}{${from_dto}
+	}
+}
+`
+
+// NullToPtr returns a pointer to the value, wrapped in n, or nil, if n is
+// NULL. It is used by the generated DTOs (see [GenerateOptions.DTOs]).
+func NullToPtr[T any](n sql.Null[T]) *T {
+	if !n.Valid {
+		return nil
+	}
+	return &n.V
+}
+
+// PtrToNull returns p as a valid [sql.Null], or NULL, if p is nil. It is used
+// by the generated DTOs (see [GenerateOptions.DTOs]).
+func PtrToNull[T any](p *T) sql.Null[T] {
+	if p == nil {
+		return sql.Null[T]{}
+	}
+	return sql.Null[T]{V: *p, Valid: true}
+}
+
+/*
+prepareDTOs renders `tpl` for every table in `columns`. The fields of the DTO
+are in the order of the columns and are named and tagged for JSON after them.
+Nullable columns become pointers.
+*/
+func prepareDTOs(tpl string, columns []columnInfo, fileString *strings.Builder) {
+	for i := 0; i < len(columns); {
+		table := columns[i].TableName
+		var fields, toDTO, fromDTO strings.Builder
+		for ; i < len(columns) && columns[i].TableName == table; i++ {
+			var typed []fieldWithGoType
+			sql2GoTypeAndTag(columns[i], &typed)
+			f := typed[0]
+			goType, to, from := f.goType, `u.`+f.name, `d.`+f.name
+			if inner, ok := strings.CutPrefix(goType, `sql.Null[`); ok {
+				goType = `*` + strings.TrimSuffix(inner, `]`)
+				to, from = `rx.NullToPtr(`+to+`)`, `rx.PtrToNull(`+from+`)`
+			}
+			fields.WriteString(sprintf("\t%s %s `json:\"%s\"`\n", f.name, goType, strings.ToLower(columns[i].CName)))
+			toDTO.WriteString(sprintf("\n\t\t%s: %s,", f.name, to))
+			fromDTO.WriteString(sprintf("\n\t\t%s: %s,", f.name, from))
+		}
+		fileString.WriteString(replace(tpl, `${`, `}`, Map{
+			`TableName`: structName(table),
+			`fields`:    strings.TrimSuffix(fields.String(), "\n"),
+			`to_dto`:    toDTO.String(),
+			`from_dto`:  fromDTO.String(),
+		}))
+	}
+}
@@ -0,0 +1,142 @@
+package rx
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var enumTemplate = `
+// ${Enum} is the type of column ${table_name}.${column}, which can have only
+// the values, allowed by its CHECK constraint.
+type ${Enum} string
+
+// The values of ${Enum}.
+const (${constants}
+)
+
+// ${Enum}Values lists all valid values of ${Enum}.
+var ${Enum}Values = []${Enum}{${values}}
+
+// Valid reports whether e is one of ${Enum}Values.
+func (e ${Enum}) Valid() bool {
+	switch e {
+	case ${values}:
+		return true
+	}
+	return false
+}
+
+// Value implements [driver.Valuer]. Invalid values are rejected with
+// [rx.ErrInvalidEnumValue] before they reach the database.
+func (e ${Enum}) Value() (driver.Value, error) {
+	if !e.Valid() {
+		return nil, fmt.Errorf("%w: %q for ${Enum}", rx.ErrInvalidEnumValue, string(e))
+	}
+	return string(e), nil
+}
+
+// Scan implements [sql.Scanner]. Values, which the database accepted, but
+// are not valid, are rejected with [rx.ErrInvalidEnumValue].
+func (e *${Enum}) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("%w: %v for ${Enum}", rx.ErrInvalidEnumValue, src)
+	}
+	if !${Enum}(s).Valid() {
+		return fmt.Errorf("%w: %q for ${Enum}", rx.ErrInvalidEnumValue, s)
+	}
+	*e = ${Enum}(s)
+	return nil
+}
+`
+
+var (
+	enumLiteral  = `'(?:[^']|'')*'`
+	enumLiterals = regexp.MustCompile(enumLiteral)
+	nonWordChars = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// enum is a column, which can have only the string values, listed in a
+// `CHECK (column IN (...))` constraint.
+type enum struct {
+	// Name is the name of the generated type - the name of the struct and
+	// the field, joined.
+	Name      string
+	TableName string
+	Column    string
+	Values    []string
+}
+
+/*
+enumOf returns the enum for `column`, if the statement, which created its table,
+has a constraint `CHECK (column IN ('value', ...))`, and the column is mapped to
+string. Otherwise it returns nil.
+*/
+func enumOf(column columnInfo) *enum {
+	if column.SQL == `` {
+		return nil
+	}
+	check := regexp.MustCompile("(?is)\\bCHECK\\s*\\(\\s*[\"`\\[]?" + regexp.QuoteMeta(column.CName) +
+		"[\"`\\]]?\\s+IN\\s*\\(\\s*(" + enumLiteral + "(?:\\s*,\\s*" + enumLiteral + ")*)\\s*\\)\\s*\\)")
+	m := check.FindStringSubmatch(column.SQL)
+	if m == nil {
+		return nil
+	}
+	e := &enum{
+		Name:      structName(column.TableName) + SnakeToCamel(strings.ToLower(column.CName)),
+		TableName: column.TableName,
+		Column:    column.CName,
+	}
+	for _, l := range enumLiterals.FindAllString(m[1], -1) {
+		e.Values = append(e.Values, strings.ReplaceAll(l[1:len(l)-1], `''`, `'`))
+	}
+	return e
+}
+
+// constants returns the names of the constants for the values of `e`. Values
+// without letters and digits and repeated names get the number of the value.
+func (e *enum) constants() []string {
+	names := make([]string, len(e.Values))
+	seen := map[string]bool{}
+	for i, v := range e.Values {
+		word := strings.Trim(nonWordChars.ReplaceAllString(strings.ToLower(v), `_`), `_`)
+		name := e.Name + strconv.Itoa(i)
+		if word != `` {
+			name = e.Name + SnakeToCamel(word)
+		}
+		if seen[name] {
+			name += strconv.Itoa(i)
+		}
+		seen[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+// prepareEnums renders `tpl` for every enum in `columns`.
+func prepareEnums(tpl string, columns []columnInfo, fileString *strings.Builder) {
+	for _, c := range columns {
+		e := enumOf(c)
+		if e == nil {
+			continue
+		}
+		var constants strings.Builder
+		names := e.constants()
+		for i, v := range e.Values {
+			constants.WriteString(sprintf("\n\t%s %s = %s", names[i], e.Name, strconv.Quote(v)))
+		}
+		fileString.WriteString(replace(tpl, `${`, `}`, Map{
+			`Enum`:       e.Name,
+			`table_name`: e.TableName,
+			`column`:     e.Column,
+			`constants`:  constants.String(),
+			`values`:     strings.Join(names, `, `),
+		}))
+	}
+}
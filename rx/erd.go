@@ -0,0 +1,104 @@
+package rx
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func collectForeignKeys(db sqlx.Ext) (fks []ForeignKey, err error) {
+	driver := db.DriverName()
+	sql, err := queryTemplate(dialectKey(`SELECT_FOREIGN_KEYS`, driver))
+	if err != nil {
+		return nil, err
+	}
+	if sql, err = replaceE(sql, Map{`current_schema`: QueryTemplates[dialectKey(`CURRENT_SCHEMA`, driver)]}); err != nil {
+		return nil, err
+	}
+	fks = []ForeignKey{}
+	err = sqlx.Select(db, &fks, db.Rebind(sql), MigrationsTable)
+	return fks, err
+}
+
+/*
+ERD returns an entity-relationship diagram of the tables in the database,
+connected via `db`, and the foreign keys between them. `format` can be
+`mermaid` (https://mermaid.js.org/syntax/entityRelationshipDiagram.html) or
+`dot` (Graphviz).
+*/
+func ERD(db *sqlx.DB, format string) (string, error) {
+	if format != `mermaid` && format != `dot` {
+		return ``, fmt.Errorf(`format can be only 'mermaid' or 'dot', but it is '%s'`, format)
+	}
+	columns, err := collectTableColumnInfo(db, ``)
+	if err != nil {
+		return ``, err
+	}
+	fks, err := collectForeignKeys(db)
+	if err != nil {
+		return ``, err
+	}
+	if format == `dot` {
+		return erdDot(columns, fks), nil
+	}
+	return erdMermaid(columns, fks), nil
+}
+
+var notMermaidType = regexp.MustCompile(`[^\w]+`)
+
+func erdMermaid(columns []columnInfo, fks []ForeignKey) string {
+	isFK := make(map[string]bool, len(fks))
+	for _, fk := range fks {
+		isFK[fk.Table+`.`+fk.Column] = true
+	}
+	var erd strings.Builder
+	erd.WriteString("erDiagram\n")
+	for i, c := range columns {
+		if i == 0 || columns[i-1].TableName != c.TableName {
+			erd.WriteString(sprintf("    %s {\n", c.TableName))
+		}
+		cType := strings.Trim(notMermaidType.ReplaceAllString(c.CType, `_`), `_`)
+		if cType == `` {
+			cType = `ANY`
+		}
+		key := ``
+		switch {
+		case c.PK > 0:
+			key = ` PK`
+		case isFK[c.TableName+`.`+c.CName]:
+			key = ` FK`
+		}
+		erd.WriteString(sprintf("        %s %s%s\n", cType, c.CName, key))
+		if i == len(columns)-1 || columns[i+1].TableName != c.TableName {
+			erd.WriteString("    }\n")
+		}
+	}
+	for _, fk := range fks {
+		erd.WriteString(sprintf("    %s ||--o{ %s : %q\n", fk.RefTable, fk.Table, fk.Column))
+	}
+	return erd.String()
+}
+
+var dotEscaper = strings.NewReplacer(`{`, `\{`, `}`, `\}`, `|`, `\|`, `<`, `\<`, `>`, `\>`, `"`, `\"`)
+
+func erdDot(columns []columnInfo, fks []ForeignKey) string {
+	var erd strings.Builder
+	erd.WriteString("digraph erd {\n\trankdir=LR;\n\tnode [shape=record];\n")
+	for i, c := range columns {
+		if i == 0 || columns[i-1].TableName != c.TableName {
+			erd.WriteString(sprintf("\t%q [label=\"{%s|", c.TableName, dotEscaper.Replace(c.TableName)))
+		}
+		erd.WriteString(dotEscaper.Replace(c.CName + ` : ` + c.CType))
+		erd.WriteString(`\l`)
+		if i == len(columns)-1 || columns[i+1].TableName != c.TableName {
+			erd.WriteString("}\"];\n")
+		}
+	}
+	for _, fk := range fks {
+		erd.WriteString(sprintf("\t%q -> %q [label=%q];\n", fk.Table, fk.RefTable, fk.Column))
+	}
+	erd.WriteString("}\n")
+	return erd.String()
+}
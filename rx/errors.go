@@ -0,0 +1,116 @@
+package rx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Typed errors for violated constraints and failed transactions. The errors,
+// returned by the drivers, are wrapped by them, so the application can check
+// for them with [errors.Is] regardless of the database engine, and still get
+// the original error with [errors.As].
+var (
+	// ErrUniqueViolation is returned, when a UNIQUE or PRIMARY KEY constraint
+	// is violated.
+	ErrUniqueViolation = errors.New(`unique violation`)
+	// ErrForeignKeyViolation is returned, when a FOREIGN KEY constraint is
+	// violated.
+	ErrForeignKeyViolation = errors.New(`foreign key violation`)
+	// ErrNotNullViolation is returned, when NULL is stored in a NOT NULL
+	// column.
+	ErrNotNullViolation = errors.New(`not null violation`)
+	// ErrCheckViolation is returned, when a CHECK constraint is violated.
+	ErrCheckViolation = errors.New(`check violation`)
+	// ErrSerializationFailure is returned, when a transaction could not be
+	// serialized with concurrent transactions and should be retried.
+	ErrSerializationFailure = errors.New(`serialization failure`)
+)
+
+// sqlStates maps SQLSTATE codes to typed errors.
+var sqlStates = map[string]error{
+	`23505`: ErrUniqueViolation,
+	`23503`: ErrForeignKeyViolation,
+	`23502`: ErrNotNullViolation,
+	`23514`: ErrCheckViolation,
+	`40001`: ErrSerializationFailure,
+}
+
+// sqliteCodes maps extended result codes of SQLite to typed errors.
+var sqliteCodes = map[sqlite3.ErrNoExtended]error{
+	sqlite3.ErrConstraintUnique:     ErrUniqueViolation,
+	sqlite3.ErrConstraintPrimaryKey: ErrUniqueViolation,
+	sqlite3.ErrConstraintForeignKey: ErrForeignKeyViolation,
+	sqlite3.ErrConstraintNotNull:    ErrNotNullViolation,
+	sqlite3.ErrConstraintCheck:      ErrCheckViolation,
+}
+
+// sqlStater is implemented by the errors of PostgreSQL drivers -
+// *pgconn.PgError of jackc/pgx and *pq.Error of lib/pq.
+type sqlStater interface {
+	SQLState() string
+}
+
+/*
+dbError wraps `err` with the typed error for its SQLSTATE code (PostgreSQL via
+pgx or lib/pq) or its extended result code (SQLite). Other errors are returned
+as they are.
+*/
+func dbError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var typed error
+	var state sqlStater
+	var lite sqlite3.Error
+	switch {
+	case errors.As(err, &state):
+		typed = sqlStates[state.SQLState()]
+	case errors.As(err, &lite):
+		typed = sqliteCodes[lite.ExtendedCode]
+	}
+	if typed == nil || errors.Is(err, typed) {
+		return err
+	}
+	return fmt.Errorf(`%w: %w`, typed, err)
+}
+
+/*
+QueryError is returned by the methods of [Rx], which execute queries, when
+they fail. It tells which operation on which table failed and with which
+query, so logs and error reporters show it without DEBUG logging. The
+original error is available via [errors.Is] and [errors.As]. [sql.ErrNoRows]
+and [ErrNotFound] are not wrapped, so `err == sql.ErrNoRows` still works.
+*/
+type QueryError struct {
+	// Op is the name of the template in [QueryTemplates], from which the
+	// query was rendered, e.g. `SELECT`.
+	Op    string
+	Table string
+	// Query is empty, if the operation failed before the query was
+	// rendered.
+	Query string
+	// Args are the bind data with the values of the parameters, listed in
+	// [RedactParams] or tagged with `redact`, replaced by [Redacted].
+	Args any
+	Err  error
+	// Plan is the query plan, if the query timed out and [ExplainOnTimeout]
+	// is set.
+	Plan []string
+}
+
+// Error returns the operation, the table, the original error and the query
+// plan, if any.
+func (e *QueryError) Error() string {
+	if len(e.Plan) > 0 {
+		return sprintf("%s %s: %s\nQuery plan:\n%s", e.Op, e.Table, e.Err, strings.Join(e.Plan, "\n"))
+	}
+	return sprintf(`%s %s: %s`, e.Op, e.Table, e.Err)
+}
+
+// Unwrap returns the original error.
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,55 @@
+package rx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+/*
+SchemaFingerprint returns a hash of the tables and their columns (names, types,
+NOT NULL, primary keys and defaults) in the database, connected via `db`.
+[Generate] embeds it in the generated code as the constant SchemaFingerprint.
+[MigrationsTable] is not included.
+*/
+func SchemaFingerprint(db *sqlx.DB) (string, error) {
+	info, err := collectTableColumnInfo(db, ``)
+	if err != nil {
+		return ``, err
+	}
+	return fingerprint(info), nil
+}
+
+func fingerprint(info []columnInfo) string {
+	h := sha256.New()
+	for _, c := range info {
+		fmt.Fprintf(h, "%s\t%s\t%s\t%t\t%d\t%s\n",
+			c.TableName, c.CName, c.CType, c.NotNull, c.PK, c.DefaultValue.String)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+/*
+CheckFingerprint returns [ErrSchemaChanged], if the schema of the database,
+connected via `db`, differs from the one, which `fingerprint` was computed for.
+Call it on startup with the constant SchemaFingerprint from the package,
+generated by [Generate], to detect that the binary was generated from another
+revision of the schema than the deployed database.
+
+	if err := rx.CheckFingerprint(rx.DB(), model.SchemaFingerprint); err != nil {
+		log.Fatal(err)
+	}
+*/
+func CheckFingerprint(db *sqlx.DB, fingerprint string) error {
+	current, err := SchemaFingerprint(db)
+	if err != nil {
+		return err
+	}
+	if current != fingerprint {
+		return fmt.Errorf(`%w: the code was generated for schema %s, but the database has %s`,
+			ErrSchemaChanged, fingerprint, current)
+	}
+	return nil
+}
@@ -0,0 +1,610 @@
+package rx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+/*
+GeneratorTemplates contains the templates, used by [Generate] and
+[GenerateFiles] to produce Go code. Replace any of them to inject your own doc
+headers, extra methods or licensing boilerplate into the generated code. See
+also [LoadGeneratorTemplates]. The keys are:
+
+  - `model_header` - the contents of the file, which is generated only once;
+  - `package_header` - the beginning of the file with structures. Its
+    placeholder `${fingerprint}` is replaced with the [SchemaFingerprint];
+  - `struct` - the code for every structure, mapped to a table;
+  - `enum` - the type with methods Valid, Value and Scan for every string
+    column with a constraint `CHECK (column IN ('value', ...))`, appended
+    after the structures. Invalid values are rejected with
+    [ErrInvalidEnumValue];
+  - `schema_objects` - a comment, listing the triggers and the virtual tables,
+    appended to the file with structures if there are any;
+  - `test_header` - the beginning of the file with tests (see
+    [GenerateOptions.Tests]);
+  - `test` - the round-trip test for every structure;
+  - `dto_header` - the beginning of the file with DTOs (see
+    [GenerateOptions.DTOs]);
+  - `dto` - the DTO with the converters for every structure;
+  - `handlers_header` - the beginning of the file with HTTP handlers (see
+    [GenerateOptions.Handlers]);
+  - `handler` - the function, registering the handlers for every table with
+    a column id.
+*/
+var GeneratorTemplates = Map{
+	`model_header`:    modelHeader,
+	`package_header`:  packageHeader,
+	`struct`:          structTemplate,
+	`enum`:            enumTemplate,
+	`schema_objects`:  schemaObjectsTemplate,
+	`test_header`:     testHeader,
+	`test`:            testTemplate,
+	`dto_header`:      dtoHeader,
+	`dto`:             dtoTemplate,
+	`handlers_header`: handlersHeader,
+	`handler`:         handlerTemplate,
+}
+
+// GenerateTests makes [Generate] and [CheckGenerated] produce also the file
+// with tests. See [GenerateOptions.Tests].
+var GenerateTests = false
+
+// GenerateBinaryUUIDs makes [Generate] and [CheckGenerated] map BLOB(16) and
+// BINARY(16) columns to [UUID]. See [GenerateOptions.BinaryUUIDs].
+var GenerateBinaryUUIDs = false
+
+// GenerateDTOs makes [Generate] and [CheckGenerated] produce also the file
+// with DTOs. See [GenerateOptions.DTOs].
+var GenerateDTOs = false
+
+// GenerateHandlers makes [Generate] and [CheckGenerated] produce also the file
+// with HTTP handlers. See [GenerateOptions.Handlers].
+var GenerateHandlers = false
+
+/*
+LoadGeneratorTemplates replaces templates in [GeneratorTemplates] with the
+contents of the files `<key>.tmpl`, found in `dir` (e.g. `struct.tmpl`).
+Missing files are ignored, so only some templates can be overridden.
+*/
+func LoadGeneratorTemplates(dir string) error {
+	for key := range GeneratorTemplates {
+		fh, err := safeOpen(filepath.Join(dir, key+`.tmpl`))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		tpl, err := io.ReadAll(fh)
+		_ = fh.Close()
+		if err != nil {
+			return err
+		}
+		GeneratorTemplates[key] = string(tpl)
+	}
+	return nil
+}
+
+// GenerateOptions configures [GenerateFiles] and [GenerateTo].
+type GenerateOptions struct {
+	// DB is the connection to the database, which tables are introspected.
+	DB *sqlx.DB
+	// Package is the name of the package, to which the generated code
+	// belongs.
+	Package string
+	// Tables is a comma-separated list of tables, for which structures will be
+	// generated. If empty, structures for all tables are generated.
+	Tables string
+	// Database describes the database in the doc-comment of the package. If
+	// empty, the name of the driver of DB is used.
+	Database string
+	// Templates override, only for this call, the templates in
+	// [GeneratorTemplates] with the same keys.
+	Templates Map
+	// Tests adds a third file `<Package>_tables_test.go` with a test for
+	// every table, which inserts a row with zero values in the table, created
+	// in an in-memory SQLite database, selects it back and compares them. It
+	// is supported only for SQLite, because the tables are created with the
+	// SQL, stored in the database.
+	Tests bool
+	// BinaryUUIDs maps BLOB(16) and BINARY(16) columns to [UUID] instead of
+	// []byte, so they are formatted as canonical strings in Go and stored as
+	// 16 bytes in the database.
+	BinaryUUIDs bool
+	// DTOs adds a file `<Package>_dto.go` with a structure `<Table>DTO` for
+	// every table, to be exposed by API layers instead of the structure with
+	// sql.Null fields. Its fields have JSON tags and nullable columns are
+	// mapped to pointers. The method DTO of the structure and the method Row
+	// of the DTO convert them to each other.
+	DTOs bool
+	// Handlers adds a file `<Package>_handlers.go` with minimal net/http
+	// handlers, which list with pagination, get, create, update and delete
+	// rows of every table with a column id, for prototypes and admin pages.
+	// The payloads are the generated structures.
+	Handlers bool
+}
+
+// GeneratedFile is a file, produced by [GenerateFiles].
+type GeneratedFile struct {
+	// Name is the name of the file without a directory.
+	Name    string
+	Content []byte
+	// Overwrite is false for files, which are meant to be modified by the
+	// programmer and must not be replaced if they already exist.
+	Overwrite bool
+}
+
+/*
+GenerateFiles generates the same files as [Generate], but returns them instead
+of writing them to a directory, so other code generators and build tools can
+embed the generation of structures. The first file contains the structures,
+mapped to tables, the second - only the package declaration. If
+[GenerateOptions.Tests] is true, a third file contains the tests. If
+[GenerateOptions.DTOs] and [GenerateOptions.Handlers] are true, the next files
+contain the DTOs and the HTTP handlers. Virtual
+tables (e.g. fts and rtree in SQLite) are mapped only if they are listed in
+[GenerateOptions.Tables]. The triggers and the virtual tables are listed in a
+comment at the end of the first file. The files are formatted with gofmt and
+the unused imports are removed from them.
+*/
+func GenerateFiles(opts GenerateOptions) ([]GeneratedFile, error) {
+	info, err := collectTableColumnInfo(opts.DB, opts.Tables)
+	if err != nil {
+		return nil, err
+	}
+	for i := range info {
+		info[i].BinaryUUID = opts.BinaryUUIDs
+	}
+	// The fingerprint is always of the whole schema.
+	schema := fingerprint(info)
+	if opts.Tables != `` {
+		if schema, err = SchemaFingerprint(opts.DB); err != nil {
+			return nil, err
+		}
+	} else {
+		info = slices.DeleteFunc(info, func(c columnInfo) bool { return c.TableKind == `virtual` })
+	}
+	if len(info) == 0 {
+		return nil, fmt.Errorf(`%w to generate structures for in %s. Check the DSN and the list of tables`,
+			ErrNoTables, loggableDSN(opts.Database))
+	}
+	objects, err := schemaObjects(opts.DB, opts.Tables)
+	if err != nil {
+		return nil, err
+	}
+	indexes := map[string][]Index{}
+	for i := range info {
+		if _, ok := indexes[info[i].TableName]; ok {
+			continue
+		}
+		if indexes[info[i].TableName], err = indexesOf(opts.DB, info[i].TableName); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Database == `` {
+		opts.Database = sprintf(`a %s connection`, opts.DB.DriverName())
+	}
+	tpl := func(key string) string {
+		if t, ok := opts.Templates[key].(string); ok {
+			return t
+		}
+		return GeneratorTemplates[key].(string)
+	}
+	var structs strings.Builder
+	preparePackageHeaderForGeneratedStructs(tpl(`package_header`), opts.Database, opts.Package, schema, &structs)
+	prepareGeneratedStructs(tpl(`struct`), info, indexes, &structs)
+	prepareEnums(tpl(`enum`), info, &structs)
+	prepareSchemaObjects(tpl(`schema_objects`), opts.Database, objects, &structs)
+	model := prepareModelFileContents(tpl(`model_header`), opts.Database, opts.Package)
+	files := []GeneratedFile{
+		{Name: opts.Package + `_tables.go`, Content: []byte(structs.String()), Overwrite: true},
+		{Name: opts.Package + `.go`, Content: []byte(model)},
+	}
+	if opts.Tests {
+		var tests strings.Builder
+		tests.WriteString(replace(tpl(`test_header`), `${`, `}`, Map{`package`: opts.Package}))
+		if err = prepareGeneratedTests(opts.DB, tpl(`test`), info, &tests); err != nil {
+			return nil, err
+		}
+		files = append(files, GeneratedFile{
+			Name: opts.Package + `_tables_test.go`, Content: []byte(tests.String()), Overwrite: true})
+	}
+	if opts.DTOs {
+		var dtos strings.Builder
+		dtos.WriteString(replace(tpl(`dto_header`), `${`, `}`, Map{`package`: opts.Package}))
+		prepareDTOs(tpl(`dto`), info, &dtos)
+		files = append(files, GeneratedFile{
+			Name: opts.Package + `_dto.go`, Content: []byte(dtos.String()), Overwrite: true})
+	}
+	if opts.Handlers {
+		var handlers strings.Builder
+		handlers.WriteString(replace(tpl(`handlers_header`), `${`, `}`, Map{`package`: opts.Package}))
+		prepareHandlers(tpl(`handler`), info, &handlers)
+		files = append(files, GeneratedFile{
+			Name: opts.Package + `_handlers.go`, Content: []byte(handlers.String()), Overwrite: true})
+	}
+	for i := range files {
+		if files[i].Content, err = formatGenerated(files[i].Name, files[i].Content); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+/*
+formatGenerated removes the unused imports from the generated Go code `src` and
+formats it with gofmt. This way the templates can import every package, which
+their code may need, and the generated code still compiles.
+*/
+func formatGenerated(name string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf(`the generated %s is not valid Go code: %w`, name, err)
+	}
+	used := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+	unused := func(spec *ast.ImportSpec) bool {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := path[strings.LastIndex(path, `/`)+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		// Blank and dot imports are kept and so are the packages, which names
+		// can not be guessed from their paths.
+		if name == `_` || name == `.` || strings.ContainsAny(name, `.-`) {
+			return false
+		}
+		return !used[name]
+	}
+	// The lines of the unused imports, or of the whole declaration, if all
+	// its imports are unused, are cut from the end, so the offsets before
+	// them stay valid.
+	var cuts [][2]int
+	for _, d := range file.Decls {
+		gen, ok := d.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		var specs [][2]int
+		for _, s := range gen.Specs {
+			if unused(s.(*ast.ImportSpec)) {
+				specs = append(specs, lineSpan(fset, src, s))
+			}
+		}
+		if len(specs) == len(gen.Specs) {
+			specs = [][2]int{lineSpan(fset, src, gen)}
+		}
+		cuts = append(cuts, specs...)
+	}
+	out := slices.Clone(src)
+	for _, c := range slices.Backward(cuts) {
+		out = slices.Delete(out, c[0], c[1])
+	}
+	return format.Source(out)
+}
+
+// lineSpan returns the offsets of the beginning of the first line of `node` in
+// `src` and of the end of its last line, including the new line.
+func lineSpan(fset *token.FileSet, src []byte, node ast.Node) [2]int {
+	start := fset.Position(node.Pos()).Offset
+	end := fset.Position(node.End()).Offset
+	start = bytes.LastIndexByte(src[:start], '\n') + 1
+	if nl := bytes.IndexByte(src[end:], '\n'); nl >= 0 {
+		end += nl + 1
+	} else {
+		end = len(src)
+	}
+	return [2]int{start, end}
+}
+
+var schemaObjectsTemplate = `
+/*
+Triggers and virtual tables in ${database}. Structures are generated for
+virtual tables only if they are listed explicitly.
+${objects}
+*/
+`
+
+// schemaObject is a trigger or a virtual table.
+type schemaObject struct {
+	Kind      string `rx:"kind"`
+	Name      string `rx:"name"`
+	TableName string `rx:"table_name"`
+	SQL       string `rx:"sql"`
+}
+
+// schemaObjects returns the triggers and the virtual tables, selected with
+// `SELECT_SCHEMA_OBJECTS` from [QueryTemplates], on `tables` or on all tables
+// if `tables` is empty.
+func schemaObjects(db *sqlx.DB, tables string) ([]schemaObject, error) {
+	query, err := queryTemplate(dialectKey(`SELECT_SCHEMA_OBJECTS`, db.DriverName()))
+	if err != nil || query == `` {
+		return nil, err
+	}
+	var objects []schemaObject
+	if err = db.Select(&objects, query); err != nil {
+		return nil, err
+	}
+	if tables == `` {
+		return objects, nil
+	}
+	names := strings.Split(tables, `,`)
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+	}
+	return slices.DeleteFunc(objects, func(o schemaObject) bool {
+		return !slices.Contains(names, o.TableName)
+	}), nil
+}
+
+// prepareSchemaObjects renders `tpl` with a list of `objects`, if there are
+// any. Virtual tables are listed with the statements, which created them.
+func prepareSchemaObjects(tpl, database string, objects []schemaObject, fileString *strings.Builder) {
+	if len(objects) == 0 {
+		return
+	}
+	var list strings.Builder
+	for _, o := range objects {
+		if o.Kind == `trigger` {
+			list.WriteString(sprintf("\n  - trigger %s on %s", o.Name, o.TableName))
+			continue
+		}
+		// The statement must not end the comment.
+		list.WriteString(sprintf("\n  - virtual table %s: %s", o.Name, strings.ReplaceAll(o.SQL, `*/`, `* /`)))
+	}
+	fileString.WriteString(replace(tpl, `${`, `}`, Map{`database`: database, `objects`: list.String()}))
+}
+
+var testHeader = `package ${package}
+
+/*
+This file will be regenerated each time you run [rx.Generate] with tests. Every
+test inserts a row with zero values in its table, created in an in-memory
+SQLite database, and selects it back to catch mistakes in the mapping of types.
+*/
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/kberov/rowx/rx"
+)
+
+func roundTrip[R rx.Rowx](t *testing.T, ddl string, row R, fix func(row, got *R)) {
+	t.Helper()
+	db := sqlx.MustConnect("sqlite3", ":memory:")
+	defer db.Close()
+	db.Mapper = reflectx.NewMapperFunc(rx.ReflectXTag, rx.CamelToSnake)
+	db.MustExec(ddl)
+	if _, err := rx.NewRxWith[R](rx.WithDB(db)).SetData([]R{row}).Insert(); err != nil {
+		if errors.Is(err, rx.ErrCheckViolation) {
+			t.Skipf("A row with zero values violates a CHECK constraint: %s", err)
+		}
+		t.Fatalf("Insert: %s", err)
+	}
+	got, err := rx.NewRxWith[R](rx.WithDB(db)).Get("1=1")
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	fix(&row, got)
+	if !reflect.DeepEqual(row, *got) {
+		t.Errorf("The row changed after a round trip:\nwant: %+v\ngot:  %+v", row, *got)
+	}
+}
+`
+
+var testTemplate = `
+func Test${TableName}RoundTrip(t *testing.T) {
+	roundTrip(t, ${ddl}, ${TableName}{${values}}, func(row, got *${TableName}) {${fix}
+	})
+}
+`
+
+/*
+prepareGeneratedTests renders `tpl` for every table in `columns` with the SQL,
+which created the table, taken from the database.
+*/
+func prepareGeneratedTests(db *sqlx.DB, tpl string, columns []columnInfo, fileString *strings.Builder) error {
+	query, err := queryTemplate(dialectKey(`SELECT_TABLE_SQL`, db.DriverName()))
+	if err != nil {
+		return err
+	}
+	if query == `` {
+		return fmt.Errorf(`tests can not be generated for driver %s`, db.DriverName())
+	}
+	for i := 0; i < len(columns); {
+		table := columns[i].TableName
+		var ddl, values, fix string
+		if err = db.Get(&ddl, db.Rebind(query), table); err != nil {
+			return err
+		}
+		for ; i < len(columns) && columns[i].TableName == table; i++ {
+			field := SnakeToCamel(strings.ToLower(columns[i].CName))
+			if strings.ToLower(columns[i].CName) == `id` {
+				// It is tagged as auto.
+				fix += "\n\t\trow." + field + " = got." + field
+			}
+			// A nil slice would be inserted as NULL and an empty enum is
+			// invalid.
+			var fields []fieldWithGoType
+			sql2GoTypeAndTag(columns[i], &fields)
+			switch fields[0].goType {
+			case `[]byte`:
+				values += field + `: []byte{}, `
+			case fields[0].enum:
+				values += field + `: ` + enumOf(columns[i]).constants()[0] + `, `
+			}
+		}
+		quoted := "`" + ddl + "`"
+		if strings.Contains(ddl, "`") {
+			quoted = strconv.Quote(ddl)
+		}
+		fileString.WriteString(replace(tpl, `${`, `}`, Map{
+			`TableName`: structName(table),
+			`ddl`:       quoted,
+			`values`:    strings.TrimSuffix(values, `, `),
+			`fix`:       fix,
+		}))
+	}
+	return nil
+}
+
+// GenerateTo writes to `w` the package header and the structures, mapped to
+// tables, as [GenerateFiles] would produce them for the first file.
+func GenerateTo(w io.Writer, opts GenerateOptions) error {
+	files, err := GenerateFiles(opts)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(files[0].Content)
+	return err
+}
+
+/*
+CheckGenerated generates the structures for the database, pointed to by `dsn`,
+in memory and compares them with the files, previously generated by [Generate]
+in `packagePath`. It returns a line diff for every stale file or an empty
+string, if the files are up to date. Files, which are meant to be modified by
+the programmer, are not compared. Useful as a "models are up to date" check in
+CI pipelines.
+*/
+func CheckGenerated(dsn, packagePath, tables string) (string, error) {
+	dh, err := safeOpen(packagePath)
+	if err != nil {
+		return ``, fmt.Errorf("%w. The directory must exist already", err)
+	}
+	defer dh.Close()
+	db, disconnect, err := connectTo(dsn)
+	if err != nil {
+		return ``, err
+	}
+	defer disconnect()
+	files, err := GenerateFiles(GenerateOptions{DB: db, Package: filepath.Base(dh.Name()), Tables: tables,
+		Database: dsn, Tests: GenerateTests, BinaryUUIDs: GenerateBinaryUUIDs, DTOs: GenerateDTOs,
+		Handlers: GenerateHandlers})
+	if err != nil {
+		return ``, err
+	}
+	var diffs strings.Builder
+	for _, f := range files {
+		if !f.Overwrite {
+			continue
+		}
+		fileName := filepath.Join(dh.Name(), f.Name)
+		existing, err := os.ReadFile(fileName) //nolint:gosec // dh.Name() is a safe path.
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return ``, err
+		}
+		diffs.WriteString(diffLines(fileName, string(existing), string(f.Content)))
+	}
+	return diffs.String(), nil
+}
+
+/*
+diffLines returns the lines, which must be removed (`-`) from `a` and added
+(`+`) to it to become `b`, or an empty string if they are equal. It uses the
+longest common subsequence of lines, which is good enough for generated files.
+The subsequence is found with the algorithm of Hirschberg, which needs memory
+only proportional to the number of lines.
+*/
+func diffLines(name, a, b string) string {
+	if a == b {
+		return ``
+	}
+	var diff strings.Builder
+	diff.WriteString(sprintf("--- %s\n+++ %s (generated)\n", name, name))
+	diffSlices(&diff, strings.Split(a, "\n"), strings.Split(b, "\n"))
+	return diff.String()
+}
+
+// diffSlices writes to `diff` the lines, which must be removed from `a` and
+// added to it to become `b`.
+func diffSlices(diff *strings.Builder, a, b []string) {
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		a, b = a[1:], b[1:]
+	}
+	var common int
+	for common < len(a) && common < len(b) && a[len(a)-1-common] == b[len(b)-1-common] {
+		common++
+	}
+	a, b = a[:len(a)-common], b[:len(b)-common]
+	switch {
+	case len(a) == 0 || len(b) == 0 || len(a) == 1:
+		i := -1
+		if len(a) == 1 {
+			i = slices.Index(b, a[0])
+		}
+		for _, line := range a {
+			if i < 0 {
+				diff.WriteString(sprintf("-%s\n", line))
+			}
+		}
+		for j, line := range b {
+			if j != i {
+				diff.WriteString(sprintf("+%s\n", line))
+			}
+		}
+	default:
+		// Split `b` where the longest common subsequences of the halves of `a`
+		// with the parts of `b` are the longest together.
+		mid := len(a) / 2
+		head := lcsLengths(a[:mid], b)
+		ra, rb := slices.Clone(a[mid:]), slices.Clone(b)
+		slices.Reverse(ra)
+		slices.Reverse(rb)
+		tail := lcsLengths(ra, rb)
+		split := 0
+		for j := range head {
+			if head[j]+tail[len(b)-j] > head[split]+tail[len(b)-split] {
+				split = j
+			}
+		}
+		diffSlices(diff, a[:mid], b[:split])
+		diffSlices(diff, a[mid:], b[split:])
+	}
+}
+
+// lcsLengths returns the lengths of the longest common subsequences of `a`
+// and every prefix of `b`.
+func lcsLengths(a, b []string) []int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
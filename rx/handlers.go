@@ -0,0 +1,156 @@
+package rx
+
+import (
+	"strconv"
+	"strings"
+)
+
+var handlersHeader = `package ${package}
+
+/*
+This file will be regenerated each time you run [rx.Generate] with handlers. It
+contains minimal net/http handlers, which list, get, create, update and delete
+rows, for prototypes and admin pages. Register them on a [http.ServeMux] with
+the functions Register<Table>Handlers. Handlers are generated only for tables
+with a column id.
+*/
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/kberov/rowx/rx"
+)
+
+// HandlersPageSize is the number of rows, listed by the handlers, when the
+// request has no parameter limit.
+var HandlersPageSize = 20
+
+// pagination returns the parameters limit and offset of the request.
+func pagination(r *http.Request) (limit, offset int) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = HandlersPageSize
+	}
+	offset, err = strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// respond writes v as JSON with status. If err is not nil, it is written
+// instead - with status 404 for [rx.ErrNotFound] and with the given status
+// for client errors. Other errors are not disclosed.
+func respond(w http.ResponseWriter, status int, v any, err error) {
+	if err != nil {
+		message := err.Error()
+		switch {
+		case errors.Is(err, rx.ErrNotFound):
+			status = http.StatusNotFound
+		case status < http.StatusBadRequest || status >= http.StatusInternalServerError:
+			status, message = http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)
+		}
+		v = map[string]string{"error": message}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+`
+
+var handlerTemplate = `
+/*
+Register${TableName}Handlers registers handlers for table ${table_name} on mux
+under prefix (e.g. "/api"):
+
+	GET    prefix/${table_name}?limit=20&offset=0 - a page of rows;
+	POST   prefix/${table_name}      - inserts a row and returns it;
+	GET    prefix/${table_name}/{id} - returns a row;
+	PUT    prefix/${table_name}/{id} - updates a row with the fields in the body;
+	DELETE prefix/${table_name}/{id} - deletes a row.
+*/
+func Register${TableName}Handlers(mux *http.ServeMux, prefix string) {
+	path := prefix + "/${table_name}"
+	mux.HandleFunc("GET "+path, func(w http.ResponseWriter, r *http.Request) {
+		limit, offset := pagination(r)
+		page, err := New${TableName}().SelectWithCount("", nil, limit, offset)
+		respond(w, http.StatusOK, page, err)
+	})
+	mux.HandleFunc("POST "+path, func(w http.ResponseWriter, r *http.Request) {
+		var row ${TableName}
+		if err := json.NewDecoder(r.Body).Decode(&row); err != nil {
+			respond(w, http.StatusBadRequest, nil, err)
+			return
+		}
+		ids, err := New${TableName}(row).InsertIDs()
+		if err != nil {
+			respond(w, http.StatusInternalServerError, nil, err)
+			return
+		}
+		created, err := New${TableName}().Find(ids[0])
+		respond(w, http.StatusCreated, created, err)
+	})
+	mux.HandleFunc("GET "+path+"/{id}", func(w http.ResponseWriter, r *http.Request) {
+		row, err := New${TableName}().Find(r.PathValue("id"))
+		respond(w, http.StatusOK, row, err)
+	})
+	mux.HandleFunc("PUT "+path+"/{id}", func(w http.ResponseWriter, r *http.Request) {
+		row, err := New${TableName}().Find(r.PathValue("id"))
+		if err != nil {
+			respond(w, http.StatusInternalServerError, nil, err)
+			return
+		}
+		id := row.ID
+		if err = json.NewDecoder(r.Body).Decode(row); err != nil {
+			respond(w, http.StatusBadRequest, nil, err)
+			return
+		}
+		row.ID = id
+		_, err = New${TableName}(*row).Update([]string{${update_columns}}, "id=:id")
+		respond(w, http.StatusOK, row, err)
+	})
+	mux.HandleFunc("DELETE "+path+"/{id}", func(w http.ResponseWriter, r *http.Request) {
+		res, err := New${TableName}().Delete("id=:id", rx.Map{"id": r.PathValue("id")})
+		if err == nil {
+			if affected, _ := res.RowsAffected(); affected == 0 {
+				err = rx.ErrNotFound
+			}
+		}
+		if err != nil {
+			respond(w, http.StatusInternalServerError, nil, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+`
+
+/*
+prepareHandlers renders `tpl` for every table in `columns` with a column `id`.
+All other columns, except generated ones, are updated by the PUT handler.
+*/
+func prepareHandlers(tpl string, columns []columnInfo, fileString *strings.Builder) {
+	for i := 0; i < len(columns); {
+		table := columns[i].TableName
+		hasID, update := false, make([]string, 0, 10)
+		for ; i < len(columns) && columns[i].TableName == table; i++ {
+			switch name := strings.ToLower(columns[i].CName); {
+			case name == `id`:
+				hasID = true
+			case !columns[i].Generated:
+				update = append(update, strconv.Quote(name))
+			}
+		}
+		if !hasID || len(update) == 0 {
+			continue
+		}
+		fileString.WriteString(replace(tpl, `${`, `}`, Map{
+			`TableName`:      structName(table),
+			`table_name`:     table,
+			`update_columns`: strings.Join(update, `, `),
+		}))
+	}
+}
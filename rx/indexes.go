@@ -0,0 +1,124 @@
+package rx
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Index describes an index on a table. Indexes are found by [IndexesOf] and
+// are also generated by [Generate] as `<TableName>Indexes` variables. For
+// expression indexes in SQLite Columns contains the expressions as written.
+type Index struct {
+	Name    string
+	Table   string
+	Columns []string
+	Unique  bool
+}
+
+// indexColumn is a row from `SELECT_INDEXES` templates.
+type indexColumn struct {
+	TableName string
+	IndexName string
+	CName     string
+	IsUnique  bool
+	// IndexSQL is the statement, which created the index. It is used to find
+	// the expressions of expression indexes.
+	IndexSQL string `rx:"index_sql"`
+}
+
+/*
+IndexesOf returns the indexes on `table` in the database, connected via [DB],
+ordered by name. The columns of every index are in the order, they appear in
+the index.
+*/
+func IndexesOf(table string) ([]Index, error) {
+	return indexesOf(DB(), table)
+}
+
+func indexesOf(db *sqlx.DB, table string) ([]Index, error) {
+	rows := []indexColumn{}
+	sql, err := queryTemplate(dialectKey(`SELECT_INDEXES`, db.DriverName()))
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Select(&rows, db.Rebind(sql), table); err != nil {
+		return nil, err
+	}
+	indexes := make([]Index, 0, len(rows))
+	for _, r := range rows {
+		if r.CName == `` {
+			n := 0
+			if l := len(indexes); l > 0 && indexes[l-1].Name == r.IndexName {
+				n = len(indexes[l-1].Columns)
+			}
+			r.CName = indexExpression(r.IndexSQL, n)
+		}
+		if l := len(indexes); l > 0 && indexes[l-1].Name == r.IndexName {
+			indexes[l-1].Columns = append(indexes[l-1].Columns, r.CName)
+			continue
+		}
+		indexes = append(indexes, Index{
+			Name: r.IndexName, Table: r.TableName, Columns: []string{r.CName}, Unique: r.IsUnique})
+	}
+	return indexes, nil
+}
+
+// indexOn matches the keyword ON in CREATE INDEX statements.
+var indexOn = regexp.MustCompile(`(?i)\sON\s`)
+
+// indexExpression returns the `n`-th indexed expression from the statement
+// `create`, which created the index.
+func indexExpression(create string, n int) string {
+	on := indexOn.FindStringIndex(create)
+	if on == nil {
+		return ``
+	}
+	open := strings.IndexByte(create[on[1]:], '(')
+	if open < 0 {
+		return ``
+	}
+	open += on[1]
+	list, _ := parenthesized(create, open)
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i <= len(list); i++ {
+		switch {
+		case i == len(list):
+			parts = append(parts, strings.TrimSpace(list[start:]))
+		case quote != 0:
+			if list[i] == quote {
+				quote = 0
+			}
+		case list[i] == '\'' || list[i] == '"' || list[i] == '`':
+			quote = list[i]
+		case list[i] == '(':
+			depth++
+		case list[i] == ')':
+			depth--
+		case list[i] == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(list[start:i]))
+			start = i + 1
+		}
+	}
+	if n >= len(parts) {
+		return ``
+	}
+	return parts[n]
+}
+
+// renderIndexes renders `indexes` as elements of a []rx.Index literal.
+func renderIndexes(indexes []Index) string {
+	var code strings.Builder
+	for _, idx := range indexes {
+		columns := make([]string, len(idx.Columns))
+		for i, c := range idx.Columns {
+			columns[i] = sprintf(`%q`, c)
+		}
+		code.WriteString(sprintf("\n\t{Name: %q, Table: %q, Columns: []string{%s}, Unique: %t},",
+			idx.Name, idx.Table, strings.Join(columns, `, `), idx.Unique))
+	}
+	return code.String()
+}
@@ -0,0 +1,121 @@
+package rx
+
+import (
+	"database/sql"
+	"slices"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TableInfo describes a table or a view, found by [Inspect].
+type TableInfo struct {
+	Name    string
+	Columns []Column
+	// PKs are the columns of the primary key in the order, they appear in it.
+	PKs     []string
+	FKs     []ForeignKey
+	Indexes []Index
+	IsView  bool
+}
+
+// Column describes a column of a table or a view.
+type Column struct {
+	Default sql.NullString
+	Name    string
+	Type    string
+	NotNull bool
+	PK      bool
+}
+
+// ForeignKey describes a column of Table, which references RefColumn of
+// RefTable.
+type ForeignKey struct {
+	Table     string `rx:"table_name"`
+	Column    string `rx:"c_name"`
+	RefTable  string
+	RefColumn string
+	// OnDelete is the action on deletion of the referenced row, like
+	// `CASCADE` or `NO ACTION`. It is empty for databases, which do not report
+	// it.
+	OnDelete string
+}
+
+/*
+ForeignKeysOf returns the foreign keys of `table` in the database, connected
+via [DB], in the order, in which they are defined. Columns of composite foreign
+keys are consecutive. To find the tables, which reference `table`, use [Inspect]
+or filter the foreign keys of every table by RefTable.
+
+	fks, err := rx.ForeignKeysOf(`users`)
+	// [{Table:users Column:group_id RefTable:groups RefColumn:id}]
+*/
+func ForeignKeysOf(table string) ([]ForeignKey, error) {
+	return foreignKeysOf(DB(), table)
+}
+
+func foreignKeysOf(db *sqlx.DB, table string) ([]ForeignKey, error) {
+	fks, err := collectForeignKeys(db)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(fks, func(fk ForeignKey) bool { return fk.Table != table }), nil
+}
+
+/*
+Inspect returns information about all tables and views in the database,
+connected via `db`, ordered by name - tables first. It uses the same templates
+from [QueryTemplates] as [GenerateFrom] and can be used to build other tools
+(validators, admin UIs...) without querying the database schema directly.
+[MigrationsTable] is omitted.
+*/
+func Inspect(db *sqlx.DB) ([]TableInfo, error) {
+	tables, err := collectColumnInfo(db, ``, `TABLE_TYPE`)
+	if err != nil {
+		return nil, err
+	}
+	views, err := collectColumnInfo(db, ``, `VIEW_TYPE`)
+	if err != nil {
+		return nil, err
+	}
+	fks, err := collectForeignKeys(db)
+	if err != nil {
+		return nil, err
+	}
+	info := appendTableInfo(nil, tables, false)
+	info = appendTableInfo(info, views, true)
+	for i := range info {
+		if info[i].IsView {
+			continue
+		}
+		for _, fk := range fks {
+			if fk.Table == info[i].Name {
+				info[i].FKs = append(info[i].FKs, fk)
+			}
+		}
+		if info[i].Indexes, err = indexesOf(db, info[i].Name); err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
+}
+
+// appendTableInfo groups `columns` by table and appends them to `info`.
+func appendTableInfo(info []TableInfo, columns []columnInfo, isView bool) []TableInfo {
+	// pk is the position of the column in the primary key for every table.
+	var pk map[string]uint8
+	for i, c := range columns {
+		if i == 0 || columns[i-1].TableName != c.TableName {
+			info = append(info, TableInfo{Name: c.TableName, IsView: isView})
+			pk = map[string]uint8{}
+		}
+		t := &info[len(info)-1]
+		t.Columns = append(t.Columns, Column{
+			Name: c.CName, Type: c.CType, Default: c.DefaultValue, NotNull: c.NotNull, PK: c.PK > 0})
+		if c.PK > 0 {
+			pk[c.CName] = c.PK
+			t.PKs = append(t.PKs, c.CName)
+			slices.SortStableFunc(t.PKs, func(a, b string) int { return int(pk[a]) - int(pk[b]) })
+		}
+	}
+	return info
+}
@@ -0,0 +1,57 @@
+package rx
+
+import (
+	"net/url"
+	"os"
+	"strings"
+)
+
+/*
+libSQL (https://github.com/tursodatabase/libsql), used by Turso, is SQLite,
+accessed remotely over HTTP or websockets. Its driver (`libsql`) must be
+imported by the application. [DB], [Migrate] and [Generate] use it for DSNs
+with scheme `libsql://`, regardless of [DriverName]. If such a DSN has no
+`authToken` parameter, the token is taken from the environment variable
+TURSO_AUTH_TOKEN. libSQL uses the SQLite templates (see [Dialects]).
+*/
+const tursoAuthTokenEnv = `TURSO_AUTH_TOKEN`
+
+// driverFor returns the name of the driver for `dsn` and the DSN to connect
+// with.
+func driverFor(dsn string) (string, string) {
+	if !strings.HasPrefix(dsn, `libsql://`) {
+		return DriverName, dsn
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return `libsql`, dsn
+	}
+	q := u.Query()
+	if token := os.Getenv(tursoAuthTokenEnv); token != `` && !q.Has(`authToken`) {
+		q.Set(`authToken`, token)
+		u.RawQuery = q.Encode()
+	}
+	return `libsql`, u.String()
+}
+
+// loggableDSN returns `dsn` with the password and the values of secret
+// parameters replaced by [Redacted].
+func loggableDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return dsn
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), Redacted)
+	} else if u.RawQuery == `` {
+		return dsn
+	}
+	q := u.Query()
+	for _, secret := range []string{`authToken`, `password`} {
+		if q.Has(secret) {
+			q.Set(secret, Redacted)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,158 @@
+package rx
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// driverSuffix matches the suffix of dialect specific keys in
+	// [QueryTemplates] like `SELECT_sqlserver`.
+	driverSuffix = regexp.MustCompile(`^(.*?)_([a-z][a-z0-9]*)$`)
+	// statement matches rendered templates, which are whole statements and
+	// not parts of other templates like `TABLE_TYPE`.
+	statement = regexp.MustCompile(`(?is)^\s*(SELECT|INSERT|UPDATE|DELETE|REPLACE|MERGE|WITH|EXPLAIN|ALTER\s+TABLE\s+\S+\s+(UPDATE|DELETE))\b`)
+	// unrendered matches placeholders, for which there was no value.
+	unrendered = regexp.MustCompile(`\$\{\w+\}`)
+)
+
+// lintSkip are keys of templates, which are not validated by
+// [ValidateTemplates], because they depend on tables, which may not exist
+// yet.
+var lintSkip = []string{`RESET_AUTOINCREMENT`, `SET_AUTOINCREMENT`, `NEXT_SEQUENCE`}
+
+/*
+ValidateTemplates renders every template from [QueryTemplates], which would be
+used for the driver of `db`, with a dummy stash, built from the columns of
+`table`, and prepares the produced statement, so the database parses it. Parts
+of other templates, templates, which are not DML statements, and empty
+templates (unsupported features) are skipped. The statements are not
+executed. Returns all found errors, joined. Each one is prefixed with the key
+of the template.
+
+	err := rx.ValidateTemplates(rx.DB(), `users`)
+*/
+func ValidateTemplates(db *sqlx.DB, table string) error {
+	columns, err := collectTableColumnInfo(db, table)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf(`table '%s' was not found`, table)
+	}
+	stash, keys := lintStash(db.DriverName(), columns)
+	var errs []error
+	for _, base := range lintKeys() {
+		key := dialectKey(base, db.DriverName())
+		if (base == `INSERT_OR_UPDATE` && len(keys) == 0) || slices.Contains(lintSkip, base) {
+			continue
+		}
+		s := stash
+		if base == `INSERT_OR_UPDATE` {
+			s = maps.Clone(stash)
+			upsertStash(s, strings.Split(stash[`columns`].(string), `,`), keys)
+		}
+		if err := lintTemplate(db, key, s); err != nil {
+			errs = append(errs, fmt.Errorf(`%s: %w`, key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lintKeys returns the sorted keys of [QueryTemplates] without their driver
+// suffixes and without the keys of parts of other templates.
+func lintKeys() []string {
+	var all strings.Builder
+	for _, v := range QueryTemplates {
+		if tpl, ok := v.(string); ok {
+			all.WriteString(tpl)
+		}
+	}
+	var bases []string
+	for key := range QueryTemplates {
+		if m := driverSuffix.FindStringSubmatch(key); m != nil {
+			key = m[1]
+		}
+		if !slices.Contains(bases, key) && !strings.Contains(all.String(), `${`+key+`}`) {
+			bases = append(bases, key)
+		}
+	}
+	slices.Sort(bases)
+	return bases
+}
+
+// lintStash returns a stash with values for all placeholders in
+// [QueryTemplates] and the primary key columns of the table.
+func lintStash(driver string, info []columnInfo) (Map, []string) {
+	var columns, placeholders, keys []string
+	for _, c := range info {
+		// Generated columns can not be inserted or updated.
+		if c.Generated {
+			continue
+		}
+		columns = append(columns, c.CName)
+		placeholders = append(placeholders, `:`+c.CName)
+		if c.PK > 0 {
+			keys = append(keys, c.CName)
+		}
+	}
+	set := sqlForSET(Logger, columns)
+	first := columns[0]
+	return Map{
+		`table`:            info[0].TableName,
+		`columns`:          strings.Join(columns, `,`),
+		`placeholders`:     `(` + strings.Join(placeholders, `,`) + `)`,
+		`SET`:              set,
+		`assignments`:      strings.TrimPrefix(set, `SET `),
+		`WHERE`:            sprintf(`WHERE %s = :%[1]s`, first),
+		`limit`:            `1`,
+		`offset`:           `0`,
+		`key`:              first,
+		`keys`:             `:` + first,
+		`GROUP_BY`:         `GROUP BY ` + first,
+		`HAVING`:           ``,
+		`ORDER_BY`:         `ORDER BY ` + first,
+		`src_table`:        info[0].TableName,
+		`src_columns`:      strings.Join(columns, `,`),
+		`query`:            `SELECT 1`,
+		`current_schema`:   QueryTemplates[dialectKey(`CURRENT_SCHEMA`, driver)],
+		`table_type`:       QueryTemplates[dialectKey(`TABLE_TYPE`, driver)],
+		`and_t_name_in`:    ``,
+		`and_policy`:       ``,
+		`inserted_columns`: `INSERTED.` + strings.Join(columns, `,INSERTED.`),
+		`deleted_columns`:  `DELETED.` + strings.Join(columns, `,DELETED.`),
+	}, keys
+}
+
+// lintTemplate renders the template `key` with `stash` and prepares it.
+func lintTemplate(db *sqlx.DB, key string, stash Map) error {
+	query, err := RenderSQLTemplateE(key, stash)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(query) == `` || !statement.MatchString(query) {
+		return nil
+	}
+	if left := unrendered.FindAllString(query, -1); left != nil {
+		return fmt.Errorf(`%w: unknown placeholders %s`, ErrTemplate, strings.Join(left, `,`))
+	}
+	// Templates for introspection use positional bind parameters.
+	if strings.Contains(query, `?`) {
+		stmt, err := db.Preparex(db.Rebind(query))
+		if err != nil {
+			return err
+		}
+		return stmt.Close()
+	}
+	stmt, err := db.PrepareNamed(query)
+	if err != nil {
+		return err
+	}
+	return stmt.Close()
+}
@@ -0,0 +1,129 @@
+package rx
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// DefaultLoaderWait is the default time window, during which [Loader.Load]
+// calls are collected into one batch.
+const DefaultLoaderWait = 2 * time.Millisecond
+
+/*
+Loader coalesces concurrent [Loader.Load] calls, made within a small time
+window ([Loader.Wait]), into a single `SELECT ... WHERE key_column IN(...)`
+query and fans the results back out to the callers. It is useful for GraphQL
+resolvers and other places, where many rows are requested one by one, but
+from different goroutines. A Loader is safe for concurrent use.
+*/
+type Loader[R Rowx] struct {
+	batch     *loaderBatch[R]
+	keyColumn string
+	// Wait is the time window during which keys are collected into one batch.
+	Wait time.Duration
+	// MaxBatch is the maximum number of keys in one batch. When reached, the
+	// batch is dispatched immediately.
+	MaxBatch int
+	// Model returns the model, with which a batch is selected, e.g. one with
+	// another DB (see [NewRxWith]), context or timeout. The calls in a batch
+	// share it, so they can not have different contexts. If nil, [NewRx] is
+	// used, so the batches are selected from [DB] with [context.Background].
+	Model func() SqlxModel[R]
+	mu    sync.Mutex
+}
+
+type loaderBatch[R Rowx] struct {
+	err  error
+	rows map[string]*R
+	seen map[string]struct{}
+	done chan struct{}
+	keys []any
+	once sync.Once
+}
+
+/*
+NewLoader returns a new [Loader] for R, which will look up rows by
+`keyColumn`. [Loader.Wait] is set to [DefaultLoaderWait] and [Loader.MaxBatch]
+to [DefaultLimit].
+*/
+func NewLoader[R Rowx](keyColumn string) *Loader[R] {
+	return &Loader[R]{keyColumn: keyColumn, Wait: DefaultLoaderWait, MaxBatch: DefaultLimit}
+}
+
+/*
+Load returns the row, which `keyColumn` value equals `key`. The call blocks
+until the batch, to which the key was added, is executed. If no row was found
+for the key, [sql.ErrNoRows] is returned, just like from [Rx.Get].
+*/
+func (l *Loader[R]) Load(key any) (*R, error) {
+	b := l.enqueue(key)
+	<-b.done
+	if b.err != nil {
+		return nilRowx[R](), b.err
+	}
+	if row, ok := b.rows[pkKey([]any{key})]; ok {
+		return row, nil
+	}
+	return nilRowx[R](), sql.ErrNoRows
+}
+
+func (l *Loader[R]) enqueue(key any) *loaderBatch[R] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.batch == nil {
+		b := &loaderBatch[R]{done: make(chan struct{}), seen: map[string]struct{}{}}
+		l.batch = b
+		time.AfterFunc(l.Wait, func() { l.dispatch(b) })
+	}
+	b := l.batch
+	k := pkKey([]any{key})
+	if _, ok := b.seen[k]; !ok {
+		b.seen[k] = struct{}{}
+		b.keys = append(b.keys, key)
+	}
+	if len(b.keys) >= l.MaxBatch {
+		l.batch = nil
+		go b.once.Do(func() { l.fetch(b) })
+	}
+	return b
+}
+
+// dispatch detaches the batch from the loader, if still attached, and
+// executes it once.
+func (l *Loader[R]) dispatch(b *loaderBatch[R]) {
+	l.mu.Lock()
+	if l.batch == b {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+	b.once.Do(func() { l.fetch(b) })
+}
+
+func (l *Loader[R]) fetch(b *loaderBatch[R]) {
+	defer close(b.done)
+	field := fieldsMap[R]().GetByPath(l.keyColumn)
+	if field == nil {
+		b.err = fmt.Errorf(`column %s not found in %T`, l.keyColumn, nilRowx[R]())
+		return
+	}
+	Logger.Debugf(`Loading %d keys by %s...`, len(b.keys), l.keyColumn)
+	model := NewRx[R]()
+	if l.Model != nil {
+		model = l.Model()
+	}
+	rows, err := model.Select(l.keyColumn+` IN(:keys)`, Map{`keys`: b.keys}, len(b.keys))
+	if err != nil {
+		b.err = err
+		return
+	}
+	b.rows = make(map[string]*R, len(rows))
+	for i := range rows {
+		v := reflectx.FieldByIndexesReadOnly(reflect.ValueOf(&rows[i]).Elem(), field.Index)
+		b.rows[pkKey([]any{v.Interface()})] = &rows[i]
+	}
+}
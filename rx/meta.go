@@ -1,9 +1,13 @@
 package rx
 
 import (
+	"context"
 	"database/sql"
+	"iter"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/labstack/gommon/log"
 )
 
 /*
@@ -18,17 +22,42 @@ SqlxModel is an interface and generic constraint for working with a set of
 database records. [Rx] fully implements SqlxModel. You can embed (extend)
 Rx to get automatically its implementation and override some of its
 methods.
+
+The methods of the interfaces SqlxDeleter, SqlxGetter, SqlxInserter,
+SqlxSelector and SqlxUpdater do not change, so their implementations and mocks
+keep compiling. New methods are added to their counterparts with the suffix Ext
+and to SqlxModel, so implement SqlxModel by embedding Rx.
 */
 type SqlxModel[R Rowx] interface {
 	Data() []R
 	SetData(data []R) (rx SqlxModel[R])
+	Explain(op, where string, binData any) ([]string, error)
+	SqlxConfigurer[R]
 	SqlxDeleter[R]
+	SqlxDeleterExt[R]
 	SqlxGetter[R]
+	SqlxGetterExt[R]
 	SqlxInserter[R]
+	SqlxInserterExt[R]
 	SqlxMeta[R]
 	SqlxSelector[R]
+	SqlxSelectorExt[R]
 	SqlxUpdater[R]
+	SqlxUpdaterExt[R]
+}
+
+/*
+SqlxConfigurer can be implemented to configure how and where the queries of a
+model are executed. It is fully implemented by [Rx].
+*/
+type SqlxConfigurer[R Rowx] interface {
+	Clone() SqlxModel[R]
 	Tx() *sqlx.Tx
+	WithCapacityHint(n int) SqlxModel[R]
+	WithContext(ctx context.Context) SqlxModel[R]
+	WithDefaultLimit(limit int) SqlxModel[R]
+	WithLogger(l *log.Logger) SqlxModel[R]
+	WithTimeout(timeout time.Duration) SqlxModel[R]
 	WithTx(queryer *sqlx.Tx) SqlxModel[R]
 }
 
@@ -45,6 +74,18 @@ type SqlxInserter[R Rowx] interface {
 	Insert() (sql.Result, error)
 }
 
+/*
+SqlxInserterExt can be implemented to insert records in other ways than
+[SqlxInserter]. It is fully implemented by [Rx].
+*/
+type SqlxInserterExt[R Rowx] interface {
+	InsertWith(opts ...InsertOption) (sql.Result, error)
+	InsertIDs(opts ...InsertOption) ([]int64, error)
+	InsertConcurrently(workers int, opts ...InsertOption) (int64, error)
+	CopyFrom(rows []R) (int64, error)
+	InsertFromSelect(srcWhere string, binData any, src SqlxMeta[Rowx], columnMap map[string]string) (sql.Result, error)
+}
+
 /*
 SqlxUpdater can be implemented to update records in a table. It is fully
 implemented by [Rx].
@@ -53,6 +94,15 @@ type SqlxUpdater[R Rowx] interface {
 	Update(fields []string, where string) (sql.Result, error)
 }
 
+/*
+SqlxUpdaterExt can be implemented to update records in other ways than
+[SqlxUpdater]. It is fully implemented by [Rx].
+*/
+type SqlxUpdaterExt[R Rowx] interface {
+	UpdateBulk(fields []string, keyColumn string) (sql.Result, error)
+	UpdateReturning(fields []string, where string) ([]R, error)
+}
+
 /*
 SqlxGetter can be implemented to get one record from the database. It is
 fully implemented by [Rx].
@@ -65,6 +115,21 @@ type SqlxGetter[R Rowx] interface {
 	Get(where string, binData ...any) (*R, error)
 }
 
+/*
+SqlxGetterExt can be implemented to get records in other ways than
+[SqlxGetter]. It is fully implemented by [Rx].
+*/
+type SqlxGetterExt[R Rowx] interface {
+	// GetInto is like Get, but scans the row into dest.
+	GetInto(dest *R, where string, bindData ...any) error
+	// Find returns the row with the given primary key.
+	Find(pk ...any) (*R, error)
+	// FindMany returns the rows with the given primary keys in their order.
+	FindMany(pks ...any) ([]R, error)
+	First(where string, bindData any, orderBy ...string) (*R, error)
+	Last(where string, bindData any, orderBy ...string) (*R, error)
+}
+
 /*
 SqlxSelector can be implemented to select records from a table or view. It
 is fully implemented by [Rx].
@@ -73,6 +138,21 @@ type SqlxSelector[R Rowx] interface {
 	Select(where string, binData any, limitAndOffset ...int) ([]R, error)
 }
 
+/*
+SqlxSelectorExt can be implemented to select records in other ways than
+[SqlxSelector]. It is fully implemented by [Rx].
+*/
+type SqlxSelectorExt[R Rowx] interface {
+	SelectAll(where string, binData any) ([]R, error)
+	SelectIter(where string, binData any) iter.Seq2[R, error]
+	Rows(where string, binData any, limitAndOffset ...int) (*sqlx.Rows, error)
+	SelectWithCount(where string, binData any, limit, offset int) (Page[R], error)
+	SelectGrouped(dest any, binData any, clauses ...Clause) error
+	// Collate returns the column with a COLLATE clause for the database.
+	Collate(column, collation string) (string, error)
+	Sample(n int, where string, binData any) ([]R, error)
+}
+
 /*
 SqlxDeleter can be implemented to delete records from a table. It is
 fully implemented by [Rx].
@@ -81,6 +161,16 @@ type SqlxDeleter[R Rowx] interface {
 	Delete(where string, binData any) (sql.Result, error)
 }
 
+/*
+SqlxDeleterExt can be implemented to delete records in other ways than
+[SqlxDeleter]. It is fully implemented by [Rx].
+*/
+type SqlxDeleterExt[R Rowx] interface {
+	DeleteReturning(where string, binData any) ([]R, error)
+	DeleteCascade(where string, binData any) (sql.Result, error)
+	Truncate() (sql.Result, error)
+}
+
 /*
 SqlxMeta can be implemented to return the name of the table in the database for
 the implementing type and the slice with its column names. It is fully
@@ -93,6 +183,12 @@ column names to be used by [Rx].
 
 If you use the commandline tool `rowx`, it will generate for you structures for
 all tables in the database and these structs will implement SqlxMeta.
+
+A struct may also have a method `Templates() Map`, returning templates, which
+override the ones from [QueryTemplates] with the same keys only for this type.
+For example a custom `SELECT`, which always joins a lookup table, while the
+rest of the operations use the default templates. Keys with a driver suffix
+like `SELECT_sqlite3` are also looked up.
 */
 type SqlxMeta[R Rowx] interface {
 	Table() string
@@ -0,0 +1,154 @@
+package rx
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// golang-migrate keeps every direction in its own file:
+	// 000001_create_users.up.sql and 000001_create_users.down.sql.
+	golangMigrateFile = regexp.MustCompile(`^(\d+)_([^.]+)\.(up|down)\.sql$`)
+	// goose keeps both directions in one file: 20251011100000_create_users.sql.
+	gooseFile      = regexp.MustCompile(`^(\d+)_([^.]+)\.sql$`)
+	gooseDirective = regexp.MustCompile(`(?i)^--\s*\+goose\s+(.+?)\s*$`)
+)
+
+/*
+readMigrations reads all migrations from `filePath`. If `filePath` is a
+directory, all `*.sql` files in it are read in lexical order, which is the
+order of the versions for goose and golang-migrate files. Every file may be in
+the native format (see [Migrate]), in goose format (`-- +goose Up`, `-- +goose
+Down`) or a golang-migrate `NNN_name.up.sql` / `NNN_name.down.sql` file.
+*/
+func readMigrations(filePath string) ([]migration, error) {
+	fh, err := safeOpen(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	info, err := fh.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return readMigrationFile(fh.Name(), fh)
+	}
+	// ReadDir returns the entries sorted by filename.
+	entries, err := os.ReadDir(fh.Name())
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]migration, 0)
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != `.sql` {
+			continue
+		}
+		more, err := readMigrationsFromFile(filepath.Join(fh.Name(), e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for i := range more {
+			migrations = append(migrations, migration{})
+			copyMigration(&migrations[len(migrations)-1], &more[i])
+		}
+	}
+	return migrations, nil
+}
+
+func readMigrationsFromFile(filePath string) ([]migration, error) {
+	fh, err := safeOpen(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+	return readMigrationFile(fh.Name(), fh)
+}
+
+// readMigrationFile detects the format of the file and parses it accordingly.
+func readMigrationFile(filePath string, r io.Reader) ([]migration, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Base(filePath)
+	if m := golangMigrateFile.FindStringSubmatch(name); m != nil {
+		migrations := []migration{{Version: m[1], Label: m[2], Direction: m[3], File: filePath}}
+		migrations[0].Statements.Write(content)
+		return migrations, nil
+	}
+	if strings.Contains(string(content), `+goose`) {
+		return parseGoose(filePath, string(content)), nil
+	}
+	return parseNative(filePath, string(content)), nil
+}
+
+// parseNative parses migrations, separated by headers, matching
+// [migrationHeader].
+func parseNative(filePath, content string) []migration {
+	migrations := make([]migration, 0)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if version, label, direction, noTx := parseMigrationHeader(line); version != `` && direction != `` {
+			migrations = append(migrations, migration{
+				Version: version, Direction: direction, Label: label, NoTx: noTx, File: filePath})
+			continue
+		}
+		// Do not collect anything until a header is found.
+		if len(migrations) == 0 {
+			continue
+		}
+		migrations[len(migrations)-1].Statements.WriteString(line)
+		migrations[len(migrations)-1].Statements.WriteString("\n")
+	}
+	return migrations
+}
+
+/*
+parseGoose parses a goose migration file. The version and the label are taken
+from the file name. `-- +goose NO TRANSACTION` marks both directions as
+[migration.NoTx]. Other goose directives (e.g. `StatementBegin`) are ignored.
+*/
+func parseGoose(filePath, content string) []migration {
+	version, label := ``, ``
+	if m := gooseFile.FindStringSubmatch(filepath.Base(filePath)); m != nil {
+		version, label = m[1], m[2]
+	}
+	migrations := make([]migration, 0, 2)
+	noTx := false
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if m := gooseDirective.FindStringSubmatch(line); m != nil {
+			switch d := strings.ToLower(m[1]); d {
+			case up.String(), down.String():
+				migrations = append(migrations, migration{
+					Version: version, Direction: d, Label: label, File: filePath})
+			case `no transaction`:
+				noTx = true
+			}
+			continue
+		}
+		if len(migrations) == 0 {
+			continue
+		}
+		migrations[len(migrations)-1].Statements.WriteString(line)
+		migrations[len(migrations)-1].Statements.WriteString("\n")
+	}
+	for i := range migrations {
+		migrations[i].NoTx = noTx
+	}
+	return migrations
+}
+
+// copyMigration copies src to dst without copying the [strings.Builder].
+func copyMigration(dst, src *migration) {
+	dst.Version, dst.Direction, dst.Label = src.Version, src.Direction, src.Label
+	dst.File, dst.NoTx = src.File, src.NoTx
+	dst.Statements.WriteString(src.Statements.String())
+}
@@ -0,0 +1,76 @@
+package rx
+
+import (
+	"slices"
+
+	"github.com/jmoiron/sqlx"
+)
+
+/*
+Option configures an instance of [Rx] at construction time. Options are
+created by [WithTable], [WithColumns], [WithDB], [WithTx], [WithLimit] and
+[WithCapacityHint] and passed to [NewRxWith].
+*/
+type Option struct {
+	apply func(*options)
+}
+
+// options are the settings of an instance of [Rx], configured by [Option]s.
+type options struct {
+	table    string
+	columns  []string
+	queryer  Ext
+	limit    int
+	capacity int
+}
+
+// WithTable sets explicitly the table name, instead of guessing it from the
+// type of the rows.
+func WithTable(table string) Option {
+	return Option{apply: func(o *options) { o.table = table }}
+}
+
+// WithColumns sets explicitly the columns of the table, instead of collecting
+// them from the type of the rows.
+func WithColumns(columns ...string) Option {
+	return Option{apply: func(o *options) { o.columns = slices.Clone(columns) }}
+}
+
+// WithDB sets the connection, on which the queries are executed, instead of
+// [DB].
+func WithDB(db *sqlx.DB) Option {
+	return Option{apply: func(o *options) { o.queryer = db }}
+}
+
+// WithTx sets the transaction, in which the queries are executed. See
+// [Rx.WithTx].
+func WithTx(tx *sqlx.Tx) Option {
+	return Option{apply: func(o *options) { o.queryer = tx }}
+}
+
+// WithLimit sets the default LIMIT for SELECT queries. See
+// [Rx.WithDefaultLimit].
+func WithLimit(limit int) Option {
+	return Option{apply: func(o *options) { o.limit = limit }}
+}
+
+// WithCapacityHint sets the initial capacity of the slices for the selected
+// rows. See [Rx.WithCapacityHint].
+func WithCapacityHint(n int) Option {
+	return Option{apply: func(o *options) { o.capacity = n }}
+}
+
+/*
+NewRxWith returns a new instance of a table model, configured by `opts`. The
+type parameter is mandatory. Use [Rx.SetData] to provide rows to it.
+
+	m := rx.NewRxWith[Users](rx.WithTable(`admins`), rx.WithLimit(10))
+*/
+func NewRxWith[R Rowx](opts ...Option) SqlxModel[R] {
+	var o options
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return &Rx[R]{r: nilRowx[R](), table: o.table, columns: o.columns, queryer: o.queryer, limit: o.limit,
+		capacity: o.capacity}
+}
@@ -0,0 +1,134 @@
+package rx
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+Progress is notified about the progress of long operations: [Migrate] (in
+statements), [Batch], [Rx.CopyFrom] and [Rx.InsertConcurrently] (in rows). Set
+[ProgressReporter] to receive the reports. Implementations must be safe for
+concurrent use, because [Rx.InsertConcurrently] reports from several
+goroutines.
+*/
+type Progress interface {
+	Report(r ProgressReport)
+}
+
+// ProgressFunc adapts a function to [Progress].
+type ProgressFunc func(r ProgressReport)
+
+// Report calls f(r).
+func (f ProgressFunc) Report(r ProgressReport) { f(r) }
+
+// ProgressReport describes how much of an operation is done.
+type ProgressReport struct {
+	// Op is the operation and the table or the file, e.g. `COPY_FROM users`.
+	Op string
+	// Unit is `rows` or `statements`.
+	Unit string
+	// Done is the number of processed units and Total - of all units, or -1,
+	// if it is not known in advance (e.g. for [Batch]).
+	Done, Total int64
+	// Elapsed is the time since the operation started.
+	Elapsed time.Duration
+	// Finished is true for the last report of the operation.
+	Finished bool
+}
+
+// Rate returns the processed units per second.
+func (r ProgressReport) Rate() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Done) / r.Elapsed.Seconds()
+}
+
+// String formats r as `COPY_FROM users: 500/1000 rows (250 rows/s)`.
+func (r ProgressReport) String() string {
+	total := ``
+	if r.Total >= 0 {
+		total = `/` + strconv.FormatInt(r.Total, 10)
+	}
+	return fmt.Sprintf(`%s: %d%s %s (%.0f %[4]s/s)`, r.Op, r.Done, total, r.Unit, r.Rate())
+}
+
+// ProgressReporter receives the reports of long operations. It is nil by
+// default - no reports are made. See [NewProgressWriter].
+var ProgressReporter Progress
+
+/*
+NewProgressWriter returns a [Progress], which writes the reports as lines to
+`w` - at most one per operation every `every` and always the last one. The
+command line tool uses it with the flag `-progress`.
+
+	rx.ProgressReporter = rx.NewProgressWriter(os.Stderr, time.Second)
+*/
+func NewProgressWriter(w io.Writer, every time.Duration) Progress {
+	return &progressWriter{w: w, every: every, last: map[string]time.Duration{}}
+}
+
+type progressWriter struct {
+	w     io.Writer
+	last  map[string]time.Duration
+	every time.Duration
+	mu    sync.Mutex
+}
+
+func (p *progressWriter) Report(r ProgressReport) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	last, ok := p.last[r.Op]
+	if ok && !r.Finished && r.Elapsed-last < p.every {
+		return
+	}
+	p.last[r.Op] = r.Elapsed
+	if r.Finished {
+		delete(p.last, r.Op)
+	}
+	_, _ = fmt.Fprintln(p.w, r.String())
+}
+
+// progress counts the units, done by an operation, and reports them to
+// [ProgressReporter]. A nil progress does nothing.
+type progress struct {
+	start    time.Time
+	op, unit string
+	total    int64
+	done     atomic.Int64
+	reporter Progress
+}
+
+// startProgress returns nil, if [ProgressReporter] is not set.
+func startProgress(op, unit string, total int64) *progress {
+	if ProgressReporter == nil {
+		return nil
+	}
+	return &progress{start: time.Now(), op: op, unit: unit, total: total, reporter: ProgressReporter}
+}
+
+// add reports `n` more done units.
+func (p *progress) add(n int64) {
+	if p == nil {
+		return
+	}
+	p.report(p.done.Add(n), false)
+}
+
+// finish makes the last report.
+func (p *progress) finish() {
+	if p == nil {
+		return
+	}
+	p.report(p.done.Load(), true)
+}
+
+func (p *progress) report(done int64, finished bool) {
+	p.reporter.Report(ProgressReport{Op: p.op, Unit: p.unit, Done: done, Total: p.total,
+		Elapsed: time.Since(p.start), Finished: finished})
+}
@@ -1,9 +1,14 @@
 package rx
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
 	"strings"
 	"unicode"
 
+	"github.com/labstack/gommon/log"
 	"github.com/valyala/fasttemplate"
 )
 
@@ -21,43 +26,565 @@ var (
 		for use by [sqlx] queries.
 	*/
 	QueryTemplates = Map{
-		`INSERT`: `INSERT INTO ${table} (${columns}) VALUES ${placeholders}`,
-		`SELECT`: `SELECT ${columns} FROM ${table} ${WHERE} LIMIT ${limit} OFFSET ${offset}`,
-		`GET`:    `SELECT ${columns} FROM ${table} ${WHERE} LIMIT 1`,
-		`UPDATE`: `UPDATE ${table} ${SET} ${WHERE}`,
-		`DELETE`: `DELETE FROM ${table} ${WHERE}`,
+		`INSERT`:         `INSERT INTO ${table} (${columns}) VALUES ${placeholders}`,
+		`SELECT`:         `SELECT ${columns} FROM ${table} ${WHERE} ${ORDER_BY} LIMIT ${limit} OFFSET ${offset}`,
+		`SELECT_ALL`:     `SELECT ${columns} FROM ${table} ${WHERE} ${ORDER_BY}`,
+		`GET`:            `SELECT ${columns} FROM ${table} ${WHERE} LIMIT 1`,
+		`COUNT`:          `SELECT COUNT(*) FROM ${table} ${WHERE}`,
+		`SELECT_GROUPED`: `SELECT ${columns} FROM ${table} ${WHERE} ${GROUP_BY} ${HAVING} ${ORDER_BY}`,
+		`UPDATE`:         `UPDATE ${table} ${SET} ${WHERE}`,
+		`UPDATE_BULK`:    `UPDATE ${table} ${SET} WHERE ${key} IN(${keys})${and_policy}`,
+		`DELETE`:         `DELETE FROM ${table} ${WHERE}`,
 		`CREATE_MIGRATIONS_TABLE`: `
 CREATE TABLE IF NOT EXISTS ${table} (
 	version UNSIGNED INT NOT NULL,
 	direction VARCHAR(4) NOT NULL CHECK(direction IN('up', 'down')),
 	file_path TEXT NOT NULL,
+	label VARCHAR(255) NOT NULL DEFAULT '',
+	superseded_by VARCHAR(14) NOT NULL DEFAULT '',
 	applied TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 	UNIQUE(version, direction)
 )`,
+		`ADD_MIGRATIONS_LABEL`: `ALTER TABLE ${table} ADD COLUMN label VARCHAR(255) NOT NULL DEFAULT ''`,
+		`ADD_MIGRATIONS_SUPERSEDED_BY`: `ALTER TABLE ${table}
+	ADD COLUMN superseded_by VARCHAR(14) NOT NULL DEFAULT ''`,
 		`SELECT_TABLE_INFO_sqlite3`: `
 SELECT t.name AS table_name, c.cid as c_id, c.name AS c_name,
-c.type as c_type, c."notnull" as not_null, c.dflt_value as default_value, c.pk as pk
--- TODO: Parse CHECK constraints(and later maybe foreign keys) from t.sql
--- , t.sql
-FROM sqlite_master t, pragma_table_info(t.name) c
-WHERE (
+c.type as c_type, c."notnull" as not_null, c.dflt_value as default_value, c.pk as pk,
+-- hidden is 2 for VIRTUAL and 3 for STORED generated columns. The expression
+-- of a generated column and the values of enums, allowed by CHECK constraints,
+-- are parsed from the CREATE TABLE statement.
+c.hidden IN(2,3) AS generated,
+CASE WHEN c.hidden IN(2,3) OR t.sql LIKE '%CHECK%' THEN t.sql ELSE '' END AS sql,
+l.strict AS strict, l.wr AS without_rowid, l.type AS table_kind,
+-- Only an INTEGER PRIMARY KEY of a rowid table is an alias of the rowid and
+-- is assigned automatically.
+l.type = 'table' AND NOT (c.pk = 1 AND upper(c.type) = 'INTEGER' AND NOT l.wr
+	AND (SELECT COUNT(*) FROM pragma_table_info(t.name) WHERE pk > 0) = 1) AS no_auto
+FROM sqlite_master t, pragma_table_list(t.name) l, pragma_table_xinfo(t.name) c
+WHERE l.schema = 'main' AND (
 	-- We replace the ${and_t_name_in} with an IN clause with comma separated
 	-- list of table names for which structures will be generated in Go.
-	t.type='table' AND t.name NOT LIKE 'sqlite%' ${and_t_name_in} AND t.name !=?)
+	-- Hidden columns of virtual tables and the shadow tables, in which
+	-- virtual tables keep their data, are skipped.
+	t.type=${table_type} AND t.name NOT LIKE 'sqlite%' ${and_t_name_in} AND t.name !=? AND c.hidden != 1
+	AND l.type != 'shadow')
 ORDER BY table_name, c_id;
 `,
+		// SELECT_TABLE_INFO is used by GenerateFrom for databases, supporting
+		// information_schema, like PostgreSQL and MySQL.
+		`SELECT_TABLE_INFO`: `
+SELECT t.name AS table_name, t.c_id, t.c_name, t.c_type, t.not_null, t.default_value, t.pk
+FROM (
+	SELECT c.table_name AS name, c.ordinal_position AS c_id, c.column_name AS c_name,
+	c.data_type AS c_type, CASE WHEN c.is_nullable = 'NO' THEN 1 ELSE 0 END AS not_null,
+	c.column_default AS default_value, CASE WHEN k.column_name IS NULL THEN 0 ELSE 1 END AS pk
+	FROM information_schema.columns c
+	JOIN information_schema.tables tb ON tb.table_schema = c.table_schema
+		AND tb.table_name = c.table_name AND tb.table_type = ${table_type}
+	LEFT JOIN information_schema.table_constraints tc ON tc.table_schema = c.table_schema
+		AND tc.table_name = c.table_name AND tc.constraint_type = 'PRIMARY KEY'
+	LEFT JOIN information_schema.key_column_usage k ON k.constraint_name = tc.constraint_name
+		AND k.table_schema = c.table_schema AND k.table_name = c.table_name
+		AND k.column_name = c.column_name
+	WHERE c.table_schema = ${current_schema}
+) t
+WHERE t.name != ? ${and_t_name_in}
+ORDER BY table_name, c_id;
+`,
+		`SELECT_FOREIGN_KEYS_sqlite3`: `
+SELECT t.name AS table_name, f."from" AS c_name, f."table" AS ref_table, f."to" AS ref_column,
+f.on_delete AS on_delete
+FROM sqlite_master t, pragma_foreign_key_list(t.name) f
+WHERE t.type='table' AND t.name NOT LIKE 'sqlite%' AND t.name != ?
+ORDER BY table_name, f.id, f.seq;
+`,
+		`SELECT_FOREIGN_KEYS`: `
+SELECT kcu.table_name AS table_name, kcu.column_name AS c_name,
+ccu.table_name AS ref_table, ccu.column_name AS ref_column, rc.delete_rule AS on_delete
+FROM information_schema.table_constraints tc
+JOIN information_schema.key_column_usage kcu ON kcu.constraint_name = tc.constraint_name
+	AND kcu.table_schema = tc.table_schema
+JOIN information_schema.constraint_column_usage ccu ON ccu.constraint_name = tc.constraint_name
+	AND ccu.table_schema = tc.table_schema
+JOIN information_schema.referential_constraints rc ON rc.constraint_name = tc.constraint_name
+	AND rc.constraint_schema = tc.table_schema
+WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = ${current_schema} AND kcu.table_name != ?
+ORDER BY table_name, kcu.ordinal_position;
+`,
+		`SELECT_FOREIGN_KEYS_mysql`: `
+SELECT k.table_name, k.column_name AS c_name,
+k.referenced_table_name AS ref_table, k.referenced_column_name AS ref_column, rc.delete_rule AS on_delete
+FROM information_schema.key_column_usage k
+JOIN information_schema.referential_constraints rc ON rc.constraint_name = k.constraint_name
+	AND rc.constraint_schema = k.table_schema
+WHERE k.referenced_table_name IS NOT NULL AND k.table_schema = ${current_schema} AND k.table_name != ?
+ORDER BY k.table_name, k.ordinal_position;
+`,
+		`SELECT_INDEXES_sqlite3`: `
+SELECT m.name AS table_name, il.name AS index_name, COALESCE(ii.name, '') AS c_name,
+il."unique" AS is_unique, COALESCE(s.sql, '') AS index_sql
+FROM sqlite_master m, pragma_index_list(m.name) il, pragma_index_info(il.name) ii
+LEFT JOIN sqlite_master s ON s.type = 'index' AND s.name = il.name
+WHERE m.type='table' AND m.name = ?
+ORDER BY index_name, ii.seqno;
+`,
+		// SELECT_INDEXES is for PostgreSQL.
+		`SELECT_INDEXES`: `
+SELECT t.relname AS table_name, i.relname AS index_name, a.attname AS c_name, ix.indisunique AS is_unique
+FROM pg_class t
+JOIN pg_index ix ON ix.indrelid = t.oid
+JOIN pg_class i ON i.oid = ix.indexrelid
+JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(ix.indkey)
+WHERE t.relname = ? AND t.relnamespace = current_schema()::regnamespace
+ORDER BY index_name, array_position(ix.indkey::int2[], a.attnum);
+`,
+		`SELECT_INDEXES_mysql`: `
+SELECT table_name, index_name, column_name AS c_name, CASE WHEN non_unique = 0 THEN 1 ELSE 0 END AS is_unique
+FROM information_schema.statistics
+WHERE table_schema = DATABASE() AND table_name = ?
+ORDER BY index_name, seq_in_index;
+`,
+		// TABLE_TYPE and VIEW_TYPE are the values, by which tables and views
+		// are distinguished in SELECT_TABLE_INFO templates.
+		`TABLE_TYPE`:                   `'BASE TABLE'`,
+		`TABLE_TYPE_sqlite3`:           `'table'`,
+		`VIEW_TYPE`:                    `'VIEW'`,
+		`VIEW_TYPE_sqlite3`:            `'view'`,
+		`CURRENT_SCHEMA`:               `current_schema()`,
+		`CURRENT_SCHEMA_mysql`:         `DATABASE()`,
+		`INSERT_FROM_SELECT`:           `INSERT INTO ${table} (${columns}) SELECT ${src_columns} FROM ${src_table} ${WHERE}`,
+		`TRUNCATE`:                     `TRUNCATE TABLE ${table}`,
+		`TRUNCATE_sqlite3`:             `DELETE FROM ${table}`,
+		`RESET_AUTOINCREMENT`:          `ALTER TABLE ${table} AUTO_INCREMENT = 1`,
+		`RESET_AUTOINCREMENT_sqlite3`:  `UPDATE sqlite_sequence SET seq = 0 WHERE name = :table`,
+		`RESET_AUTOINCREMENT_postgres`: `SELECT setval(pg_get_serial_sequence(:table, 'id'), 1, false)`,
+		`RESET_AUTOINCREMENT_pgx`:      `SELECT setval(pg_get_serial_sequence(:table, 'id'), 1, false)`,
+		`HAS_AUTOINCREMENT`:            ``,
+		`HAS_AUTOINCREMENT_sqlite3`:    `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'sqlite_sequence'`,
+		`EXPLAIN`:                      `EXPLAIN ${query}`,
+		`EXPLAIN_sqlite3`:              `EXPLAIN QUERY PLAN ${query}`,
+
+		// INSERT_RETURNING is appended to INSERT queries by Rx.InsertIDs for
+		// drivers, which do not support LastInsertId.
+		`INSERT_RETURNING`:          ``,
+		`INSERT_RETURNING_postgres`: ` RETURNING id`,
+		`INSERT_RETURNING_pgx`:      ` RETURNING id`,
+
+		// Templates for OrIgnore and OrReplace. The generic ones are for MySQL.
+		`INSERT_OR_IGNORE`:          `INSERT IGNORE INTO ${table} (${columns}) VALUES ${placeholders}`,
+		`INSERT_OR_IGNORE_sqlite3`:  `INSERT OR IGNORE INTO ${table} (${columns}) VALUES ${placeholders}`,
+		`INSERT_OR_IGNORE_postgres`: `INSERT INTO ${table} (${columns}) VALUES ${placeholders} ON CONFLICT DO NOTHING`,
+		`INSERT_OR_IGNORE_pgx`:      `INSERT INTO ${table} (${columns}) VALUES ${placeholders} ON CONFLICT DO NOTHING`,
+		`INSERT_OR_REPLACE`:         `REPLACE INTO ${table} (${columns}) VALUES ${placeholders}`,
+
+		// Templates for OrUpdate. The generic one is the standard MERGE
+		// statement, used by SQL Server, PostgreSQL 15+ and others.
+		`INSERT_OR_UPDATE`: `MERGE INTO ${table} AS t USING (SELECT ${source}) AS s ON (${on})
+WHEN MATCHED THEN UPDATE SET ${merge_set}
+WHEN NOT MATCHED THEN INSERT (${columns}) VALUES (${merge_values});`,
+		`INSERT_OR_UPDATE_sqlite3`:  `${INSERT_ON_CONFLICT}`,
+		`INSERT_OR_UPDATE_postgres`: `${INSERT_ON_CONFLICT}`,
+		`INSERT_OR_UPDATE_pgx`:      `${INSERT_ON_CONFLICT}`,
+		`INSERT_OR_UPDATE_mysql`:    `INSERT INTO ${table} (${columns}) VALUES ${placeholders} ON DUPLICATE KEY UPDATE ${values_set}`,
+		`INSERT_ON_CONFLICT`:        `INSERT INTO ${table} (${columns}) VALUES ${placeholders} ON CONFLICT (${keys}) DO UPDATE SET ${excluded_set}`,
+
+		// Templates for Rx.UpdateReturning and Rx.DeleteReturning. An empty
+		// template means, that the database does not support RETURNING.
+		`UPDATE_RETURNING`:       `UPDATE ${table} ${SET} ${WHERE} RETURNING ${columns}`,
+		`UPDATE_RETURNING_mysql`: ``,
+		`DELETE_RETURNING`:       `DELETE FROM ${table} ${WHERE} RETURNING ${columns}`,
+		`DELETE_RETURNING_mysql`: ``,
+
+		// Templates for OrderByCollate and Rx.Collate. Collations in
+		// PostgreSQL (e.g. the ICU ones like bg-x-icu) are identifiers and in
+		// ClickHouse they are locales, given as strings.
+		`COLLATE`:            `${column} COLLATE ${collation}`,
+		`COLLATE_postgres`:   `${column} COLLATE "${collation}"`,
+		`COLLATE_pgx`:        `${column} COLLATE "${collation}"`,
+		`COLLATE_clickhouse`: `${column} COLLATE '${collation}'`,
+
+		// Template for Rx.First and Rx.Last.
+		`FIRST`: `SELECT ${columns} FROM ${table} ${WHERE} ${ORDER_BY} LIMIT 1`,
+
+		// Templates for Rx.Sample.
+		`SAMPLE`:       `SELECT ${columns} FROM ${table} ${WHERE} ORDER BY RANDOM() LIMIT ${limit}`,
+		`SAMPLE_mysql`: `SELECT ${columns} FROM ${table} ${WHERE} ORDER BY RAND() LIMIT ${limit}`,
+
+		// SQL Server has no LIMIT. OFFSET ... FETCH requires ORDER BY, so
+		// NO_ORDER_BY is rendered in ${ORDER_BY}, if there is no order.
+		`SELECT_sqlserver`:      `SELECT ${columns} FROM ${table} ${WHERE} ${ORDER_BY} OFFSET ${offset} ROWS FETCH NEXT ${limit} ROWS ONLY`,
+		`NO_ORDER_BY`:           ``,
+		`NO_ORDER_BY_sqlserver`: `ORDER BY (SELECT NULL)`,
+		`FIRST_sqlserver`:       `SELECT TOP 1 ${columns} FROM ${table} ${WHERE} ${ORDER_BY}`,
+		`SAMPLE_sqlserver`:      `SELECT TOP ${limit} ${columns} FROM ${table} ${WHERE} ORDER BY NEWID()`,
+		// SQL Server returns the rows with OUTPUT instead of RETURNING.
+		`UPDATE_RETURNING_sqlserver`: `UPDATE ${table} ${SET} OUTPUT ${inserted_columns} ${WHERE}`,
+		`DELETE_RETURNING_sqlserver`: `DELETE FROM ${table} OUTPUT ${deleted_columns} ${WHERE}`,
+
+		// DuckDB has no LastInsertId and supports the syntax of SQLite for
+		// upserts. Its catalog is queried via information_schema and the
+		// duckdb_* table functions.
+		`INSERT_RETURNING_duckdb`:  ` RETURNING id`,
+		`INSERT_OR_IGNORE_duckdb`:  `INSERT OR IGNORE INTO ${table} (${columns}) VALUES ${placeholders}`,
+		`INSERT_OR_REPLACE_duckdb`: `INSERT OR REPLACE INTO ${table} (${columns}) VALUES ${placeholders}`,
+		`INSERT_OR_UPDATE_duckdb`:  `${INSERT_ON_CONFLICT}`,
+		`SELECT_FOREIGN_KEYS_duckdb`: `
+SELECT table_name, c_name, ref_table, ref_column FROM (
+	SELECT table_name, constraint_index, referenced_table AS ref_table,
+	UNNEST(constraint_column_names) AS c_name, UNNEST(referenced_column_names) AS ref_column
+	FROM duckdb_constraints()
+	WHERE constraint_type = 'FOREIGN KEY' AND schema_name = current_schema() AND table_name != ?
+) ORDER BY table_name, constraint_index;
+`,
+		`SELECT_INDEXES_duckdb`: `
+SELECT table_name, index_name, TRIM(UNNEST(string_split(TRIM(expressions, '[]'), ','))) AS c_name, is_unique
+FROM duckdb_indexes()
+WHERE table_name = ? AND schema_name = current_schema()
+ORDER BY index_name;
+`,
+
+		// ClickHouse. See clickhouse.go.
+		`UPDATE_clickhouse`:           `ALTER TABLE ${table} UPDATE ${assignments} ${WHERE}`,
+		`UPDATE_BULK_clickhouse`:      `ALTER TABLE ${table} UPDATE ${assignments} WHERE ${key} IN(${keys})${and_policy}`,
+		`DELETE_clickhouse`:           `ALTER TABLE ${table} DELETE ${WHERE}`,
+		`UPDATE_RETURNING_clickhouse`: ``,
+		`DELETE_RETURNING_clickhouse`: ``,
+		`SAMPLE_clickhouse`:           `SELECT ${columns} FROM ${table} ${WHERE} ORDER BY rand() LIMIT ${limit}`,
+		`CURRENT_SCHEMA_clickhouse`:   `currentDatabase()`,
+		`TABLE_TYPE_clickhouse`:       `0`,
+		`VIEW_TYPE_clickhouse`:        `1`,
+		`SELECT_TABLE_INFO_clickhouse`: `
+SELECT c.table AS table_name, c.position AS c_id, c.name AS c_name, c.type AS c_type,
+CASE WHEN c.type LIKE 'Nullable(%' THEN 0 ELSE 1 END AS not_null,
+c.default_expression AS default_value, c.is_in_primary_key AS pk
+FROM system.columns c
+JOIN system.tables t ON t.database = c.database AND t.name = c.table
+WHERE c.database = ${current_schema} AND (t.engine LIKE '%View') = ${table_type}
+	${and_t_name_in} AND t.name != ?
+ORDER BY table_name, c_id;
+`,
+		// There are no foreign keys in ClickHouse.
+		`SELECT_FOREIGN_KEYS_clickhouse`: `
+SELECT '' AS table_name, '' AS c_name, '' AS ref_table, '' AS ref_column WHERE 0 AND ? != '';
+`,
+		// Data skipping indexes. Their expressions are reported as columns.
+		`SELECT_INDEXES_clickhouse`: `
+SELECT table AS table_name, name AS index_name, expr AS c_name, 0 AS is_unique
+FROM system.data_skipping_indices
+WHERE database = currentDatabase() AND table = ?
+ORDER BY index_name;
+`,
+
+		// Templates for Session.Savepoint, Session.RollbackTo and
+		// Session.Release. An empty template is not executed.
+		`SAVEPOINT`:                       `SAVEPOINT ${name}`,
+		`ROLLBACK_TO_SAVEPOINT`:           `ROLLBACK TO SAVEPOINT ${name}`,
+		`RELEASE_SAVEPOINT`:               `RELEASE SAVEPOINT ${name}`,
+		`SAVEPOINT_sqlserver`:             `SAVE TRANSACTION ${name}`,
+		`ROLLBACK_TO_SAVEPOINT_sqlserver`: `ROLLBACK TRANSACTION ${name}`,
+		`RELEASE_SAVEPOINT_sqlserver`:     ``,
+
+		// COPY_FROM loads rows in bulk for Rx.CopyFrom. Empty means, that
+		// the rows are inserted with Rx.Insert. ${reader} is the name of the
+		// reader, registered by RegisterReaderHandler.
+		`COPY_FROM`:          ``,
+		`COPY_FROM_postgres`: `COPY ${table} (${columns}) FROM STDIN`,
+		`COPY_FROM_mysql`:    `LOAD DATA LOCAL INFILE 'Reader::${reader}' INTO TABLE ${table} (${columns})`,
+
+		// Templates for NextSequence and SetAutoIncrement. An empty template
+		// means, that the database has no sequences.
+		`NEXT_SEQUENCE`:              `SELECT NEXT VALUE FOR ${name}`,
+		`NEXT_SEQUENCE_postgres`:     `SELECT nextval(:name)`,
+		`NEXT_SEQUENCE_pgx`:          `SELECT nextval(:name)`,
+		`NEXT_SEQUENCE_duckdb`:       `SELECT nextval(:name)`,
+		`NEXT_SEQUENCE_mysql`:        ``,
+		`NEXT_SEQUENCE_sqlite3`:      `UPDATE sqlite_sequence SET seq = seq + 1 WHERE name = :name RETURNING seq`,
+		`SET_AUTOINCREMENT`:          `ALTER TABLE ${table} AUTO_INCREMENT = ${next}`,
+		`SET_AUTOINCREMENT_postgres`: `SELECT setval(pg_get_serial_sequence(:table, 'id'), :next, false)`,
+		`SET_AUTOINCREMENT_pgx`:      `SELECT setval(pg_get_serial_sequence(:table, 'id'), :next, false)`,
+		`SET_AUTOINCREMENT_sqlite3`:  `UPDATE sqlite_sequence SET seq = :next - 1 WHERE name = :table`,
+
+		// SELECT_TABLE_SQL returns the SQL, which created a table. It is used
+		// for the tests, produced by GenerateFiles.
+		`SELECT_TABLE_SQL`:         ``,
+		`SELECT_TABLE_SQL_sqlite3`: `SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?`,
+
+		// Table for the checkpoints of Batch.
+		`CREATE_CHECKPOINTS_TABLE`: `
+CREATE TABLE IF NOT EXISTS ${table} (
+	job VARCHAR(255) NOT NULL PRIMARY KEY,
+	last_key VARCHAR(255) NOT NULL,
+	updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+)`,
+
+		// SELECT_SCHEMA returns the statements, which create the schema, for
+		// [Squash]. Tables come first in the order, in which they were
+		// created. An empty template means that squashing is not supported.
+		`SELECT_SCHEMA`: ``,
+		`SELECT_SCHEMA_sqlite3`: `
+SELECT sql FROM sqlite_master
+WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite%' AND name != ?
+ORDER BY CASE type WHEN 'table' THEN 0 WHEN 'index' THEN 1 WHEN 'view' THEN 2 ELSE 3 END, rowid`,
+
+		// SELECT_SCHEMA_OBJECTS returns the triggers and the virtual tables,
+		// which are listed in a comment by [GenerateFiles]. An empty template
+		// means that there are no such objects.
+		`SELECT_SCHEMA_OBJECTS`: ``,
+		`SELECT_SCHEMA_OBJECTS_sqlite3`: `
+SELECT CASE type WHEN 'trigger' THEN 'trigger' ELSE 'virtual table' END AS kind,
+name, tbl_name AS table_name, sql FROM sqlite_master
+WHERE (type = 'trigger' OR type = 'table' AND sql LIKE 'CREATE VIRTUAL TABLE%') AND name NOT LIKE 'sqlite%'
+ORDER BY kind DESC, table_name, name`,
 	}
 	replace = fasttemplate.ExecuteStringStd
 )
 
 /*
-RenderSQLTemplate gets the template from [QueryTemplates], replaces potential
-partial SQL keys from [QueryTemplates] and then the keys from the given stash
-with values. Returns the produced SQL. Panics if key was not found or is not of
-the expected type (string).
+RenderSQLTemplate is like [RenderSQLTemplateE], but panics on error.
+
+Deprecated: Use [RenderSQLTemplateE], so a mistake in a template cannot crash
+the application.
 */
 func RenderSQLTemplate(key string, stash map[string]any) string {
-	return replace(replace(QueryTemplates[key].(string), "${", "}", QueryTemplates), "${", "}", stash)
+	sql, err := RenderSQLTemplateE(key, stash)
+	if err != nil {
+		panic(err)
+	}
+	return sql
+}
+
+// ErrTemplate is returned by [RenderSQLTemplateE] for missing templates and
+// values of unexpected types.
+var ErrTemplate = errors.New(`bad template`)
+
+/*
+RenderSQLTemplateE gets the template from [QueryTemplates], replaces potential
+partial SQL keys from [QueryTemplates] and then the keys from the given stash
+with values. Returns the produced SQL. Placeholders, for which there are no
+values, are left as they are. Returns an error, wrapping [ErrTemplate], if the
+key was not found or the template or a value is not a string.
+*/
+func RenderSQLTemplateE(key string, stash map[string]any) (string, error) {
+	return renderSQL(QueryTemplates, key, stash)
+}
+
+// renderSQL is like [RenderSQLTemplateE], but takes the template `key` from
+// `templates`. Partials are still taken from [QueryTemplates].
+func renderSQL(templates Map, key string, stash map[string]any) (string, error) {
+	tpl, err := templateIn(templates, key)
+	if err != nil {
+		return ``, err
+	}
+	sql, err := replaceE(tpl, QueryTemplates)
+	if err != nil {
+		return ``, fmt.Errorf(`%s: %w`, key, err)
+	}
+	return replaceE(sql, stash)
+}
+
+// queryTemplate returns the template `key` from [QueryTemplates].
+func queryTemplate(key string) (string, error) {
+	return templateIn(QueryTemplates, key)
+}
+
+func templateIn(templates Map, key string) (string, error) {
+	tpl, ok := templates[key].(string)
+	if !ok {
+		return ``, fmt.Errorf(`%w: %s is %T, not a string`, ErrTemplate, key, templates[key])
+	}
+	return tpl, nil
+}
+
+// replaceE is like replace (fasttemplate.ExecuteStringStd), but returns an
+// error instead of panicking, if a value is not a string or []byte.
+func replaceE(tpl string, values map[string]any) (string, error) {
+	return fasttemplate.ExecuteFuncStringWithErr(tpl, `${`, `}`, func(w io.Writer, tag string) (int, error) {
+		v, ok := values[tag]
+		switch v := v.(type) {
+		case string:
+			return w.Write([]byte(v))
+		case []byte:
+			return w.Write(v)
+		case fasttemplate.TagFunc:
+			return v(w, tag)
+		}
+		if !ok {
+			return w.Write([]byte(`${` + tag + `}`))
+		}
+		return 0, fmt.Errorf(`%w: value of %s is %T, not a string`, ErrTemplate, tag, v)
+	})
+}
+
+/*
+Dialects maps names of drivers to the names of other drivers, which templates
+in [QueryTemplates] are used for them, because they speak the same SQL
+dialect.
+
+	rx.Dialects[`pgx/v5`] = `pgx`
+*/
+var Dialects = map[string]string{
+	`libsql`: `sqlite3`,
+}
+
+/*
+dialectKey returns `key_driverName` if such a template exists in
+[QueryTemplates]. Otherwise it returns `key`. This way templates can be
+specialized for a database engine. `driverName` is replaced by its dialect from
+[Dialects] first.
+*/
+func dialectKey(key, driverName string) string {
+	return dialectKeyIn(QueryTemplates, key, driverName)
+}
+
+// dialectKeyIn is like dialectKey, but looks for `key_driverName` in
+// `templates`.
+func dialectKeyIn(templates Map, key, driverName string) string {
+	if dialect, ok := Dialects[driverName]; ok {
+		driverName = dialect
+	}
+	if _, ok := templates[key+`_`+driverName]; ok {
+		return key + `_` + driverName
+	}
+	return key
+}
+
+// InsertOption modifies the INSERT statement, rendered by [Rx.InsertWith] and
+// [Rx.InsertIDs]. See [OrIgnore], [OrReplace] and [OrUpdate].
+type InsertOption struct {
+	key  string
+	keys []string
+}
+
+// OrIgnore makes [Rx.InsertWith] skip rows, which violate a UNIQUE constraint.
+// It renders `INSERT_OR_IGNORE` from [QueryTemplates].
+func OrIgnore() InsertOption {
+	return InsertOption{key: `INSERT_OR_IGNORE`}
+}
+
+// OrReplace makes [Rx.InsertWith] replace existing rows, which violate a
+// UNIQUE constraint. It renders `INSERT_OR_REPLACE` from [QueryTemplates].
+// There is no such template for PostgreSQL yet.
+func OrReplace() InsertOption {
+	return InsertOption{key: `INSERT_OR_REPLACE`}
+}
+
+/*
+OrUpdate makes the INSERT an upsert: rows, which `keys` (columns with a UNIQUE
+constraint or the primary key) match existing rows, update the other columns
+of these rows. It is rendered as MERGE by default (SQL Server and other
+databases, following the standard), as `ON CONFLICT (keys) DO UPDATE` for
+SQLite and PostgreSQL and as `ON DUPLICATE KEY UPDATE` for MySQL. See
+`INSERT_OR_UPDATE` in [QueryTemplates]. Only MySQL finds the keys itself - on
+the other databases the insert fails with [ErrNoUpsertKeys] without `keys`.
+*/
+func OrUpdate(keys ...string) InsertOption {
+	return InsertOption{key: `INSERT_OR_UPDATE`, keys: keys}
+}
+
+// ErrNoUpsertKeys is returned by the inserts with [OrUpdate] without keys, if
+// the database needs them.
+var ErrNoUpsertKeys = errors.New(`rx.OrUpdate needs the columns of a UNIQUE constraint or the primary key`)
+
+// upsertStash adds to `stash` the parts of the `INSERT_OR_UPDATE` templates.
+// Without `keys`, the parts, which need them, render [ErrNoUpsertKeys].
+func upsertStash(stash Map, columns, keys []string) {
+	var source, on, mergeSet, mergeValues, excludedSet, valuesSet []string
+	for _, c := range columns {
+		source = append(source, sprintf(`:%s AS %[1]s`, c))
+		mergeValues = append(mergeValues, `s.`+c)
+		if slices.Contains(keys, c) {
+			continue
+		}
+		mergeSet = append(mergeSet, sprintf(`%s = s.%[1]s`, c))
+		excludedSet = append(excludedSet, sprintf(`%s = excluded.%[1]s`, c))
+		valuesSet = append(valuesSet, sprintf(`%s = VALUES(%[1]s)`, c))
+	}
+	for _, k := range keys {
+		on = append(on, sprintf(`t.%s = s.%[1]s`, k))
+	}
+	stash[`keys`] = strings.Join(keys, `,`)
+	stash[`source`] = strings.Join(source, `,`)
+	stash[`on`] = strings.Join(on, ` AND `)
+	stash[`merge_set`] = strings.Join(mergeSet, `,`)
+	stash[`merge_values`] = strings.Join(mergeValues, `,`)
+	stash[`excluded_set`] = strings.Join(excludedSet, `,`)
+	stash[`values_set`] = strings.Join(valuesSet, `,`)
+	if len(keys) == 0 {
+		noKeys := fasttemplate.TagFunc(func(io.Writer, string) (int, error) { return 0, ErrNoUpsertKeys })
+		stash[`keys`], stash[`on`] = noKeys, noKeys
+	}
+}
+
+/*
+Clause is a part of an SQL statement, like `GROUP BY` or `HAVING`. Clauses are
+constructed by [Where], [GroupBy], [Having], [OrderBy] and [Aggregate] and
+passed to methods like [Rx.SelectGrouped], which put them on their place in
+the rendered template.
+*/
+type Clause struct {
+	key     string
+	sql     string
+	columns []string
+	// collation is set by OrderByCollate. Then sql is only the column.
+	collation string
+}
+
+// Where returns a WHERE [Clause]. The keyword `WHERE` can be omitted.
+func Where(condition string) Clause {
+	return Clause{key: `WHERE`, sql: ifWhere(condition)}
+}
+
+/*
+GroupBy returns a `GROUP BY` [Clause]. The columns are also added to the list
+of selected columns.
+*/
+func GroupBy(columns ...string) Clause {
+	return Clause{key: `GROUP_BY`, sql: `GROUP BY ` + strings.Join(columns, `,`), columns: columns}
+}
+
+// Having returns a HAVING [Clause]. It may contain named bind parameters.
+func Having(condition string) Clause {
+	return Clause{key: `HAVING`, sql: `HAVING ` + condition}
+}
+
+// OrderBy returns an `ORDER BY` [Clause].
+func OrderBy(columns ...string) Clause {
+	return Clause{key: `ORDER_BY`, sql: `ORDER BY ` + strings.Join(columns, `,`)}
+}
+
+/*
+OrderByCollate returns an `ORDER BY` [Clause], which sorts by `column` with
+`collation`, e.g. `NOCASE` in SQLite or `bg-x-icu` in PostgreSQL. It is
+rendered by [Rx.Collate] for the dialect of the database. `column` may end with
+ASC or DESC. Several `ORDER BY` clauses are joined in the order, they are
+passed.
+
+	err := rx.NewRx[Users]().SelectGrouped(&dest, nil, rx.GroupBy(`first_name`),
+		rx.OrderByCollate(`first_name DESC`, `NOCASE`), rx.OrderBy(`last_name`))
+*/
+func OrderByCollate(column, collation string) Clause {
+	return Clause{key: `ORDER_BY`, sql: column, collation: collation}
+}
+
+/*
+Aggregate returns a [Clause], which only adds expressions to the list of
+selected columns, e.g. `COUNT(*) AS users_count`. The aliases must match the
+columns of the destination structure.
+*/
+func Aggregate(expressions ...string) Clause {
+	return Clause{columns: expressions}
+}
+
+// toColumn makes `field` snake_case if it starts with a capital letter.
+func toColumn(field string) string {
+	for _, r := range field {
+		if unicode.IsUpper(r) {
+			return CamelToSnake(field)
+		}
+		break
+	}
+	return field
 }
 
 /*
@@ -66,19 +593,16 @@ slice of columns` names. It also makes each column snake_case if it contains a
 capital letter.
 */
 func SQLForSET(columns []string) string {
+	return sqlForSET(Logger, columns)
+}
+
+func sqlForSET(l *log.Logger, columns []string) string {
 	var set strings.Builder
 	set.WriteString(`SET`)
 	for _, v := range columns {
-		for _, r := range v {
-			if unicode.IsUpper(r) {
-				v = CamelToSnake(v)
-				break
-			}
-			break
-		}
-		set.WriteString(sprintf(` %s = :%[1]s,`, v))
+		set.WriteString(sprintf(` %s = :%[1]s,`, toColumn(v)))
 	}
 	setStr := strings.TrimSuffix(set.String(), `,`)
-	Logger.Debugf(`SQL from SQLForSET:'%s'`, setStr)
+	l.Debugf(`SQL from SQLForSET:'%s'`, setStr)
 	return setStr
 }
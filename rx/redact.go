@@ -0,0 +1,122 @@
+package rx
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/jmoiron/sqlx/reflectx"
+	"github.com/labstack/gommon/log"
+)
+
+// Redacted replaces the values of redacted parameters in the logs.
+const Redacted = `[REDACTED]`
+
+/*
+RedactParams lists the names of bind parameters and columns, which values are
+replaced with [Redacted], when queries and their arguments are logged at DEBUG
+level. Columns can also be redacted per type with the tag option `redact`:
+`rx:"password,redact"`.
+*/
+var RedactParams = []string{}
+
+// redactedNames returns the names of the redacted parameters for R.
+func redactedNames[R Rowx]() map[string]bool {
+	names := make(map[string]bool, len(RedactParams))
+	for _, n := range RedactParams {
+		names[n] = true
+	}
+	if reflect.TypeFor[R]().Kind() != reflect.Struct {
+		return names
+	}
+	for _, fi := range fieldsMap[R]().Index {
+		if _, ok := fi.Options[`redact`]; ok {
+			names[fi.Path] = true
+			names[fi.Name] = true
+		}
+	}
+	return names
+}
+
+/*
+redact returns a representation of `bindData` for logging, in which the values
+of the parameters in `names` are replaced with [Redacted]. Structs and maps
+become [Map], slices become []any. Other values are returned as is.
+*/
+func redact(bindData any, names map[string]bool) any {
+	v := reflect.Indirect(reflect.ValueOf(bindData))
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return bindData
+		}
+		rows := make([]any, v.Len())
+		for i := range v.Len() {
+			rows[i] = redact(v.Index(i).Interface(), names)
+		}
+		return rows
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return bindData
+		}
+		m := make(Map, v.Len())
+		for _, k := range v.MapKeys() {
+			m[k.String()] = redactValue(k.String(), v.MapIndex(k).Interface(), names)
+		}
+		return m
+	case reflect.Struct:
+		tm := DB().Mapper.TypeMap(v.Type())
+		m := make(Map, len(tm.Index))
+		for _, fi := range tm.Index {
+			// Log only the leaves - columns and nested bind parameters.
+			if slices.ContainsFunc(fi.Children, func(c *reflectx.FieldInfo) bool { return c != nil }) {
+				continue
+			}
+			if value, ok := loggableField(v, fi.Index); ok {
+				m[fi.Path] = redactValue(fi.Path, value, names)
+			}
+		}
+		return m
+	default:
+		return bindData
+	}
+}
+
+func redactValue(name string, value any, names map[string]bool) any {
+	if names[name] {
+		return Redacted
+	}
+	return value
+}
+
+// loggableField returns the value of the field of `v` with the given index, if
+// it is reachable and exported.
+func loggableField(v reflect.Value, index []int) (any, bool) {
+	for _, i := range index {
+		if v = reflect.Indirect(v); !v.IsValid() {
+			return nil, false
+		}
+		v = v.Field(i)
+	}
+	if !v.CanInterface() {
+		return nil, false
+	}
+	return v.Interface(), true
+}
+
+// redacted returns the names of the redacted parameters for R, or nil, when
+// DEBUG messages are not logged by this instance.
+func (m *Rx[R]) redacted() map[string]bool {
+	if m.logger().Level() > log.DEBUG {
+		return nil
+	}
+	return redactedNames[R]()
+}
+
+// loggable returns `bindData` with the redacted parameters for R replaced, if
+// there are any.
+func (m *Rx[R]) loggable(bindData any) any {
+	if names := m.redacted(); len(names) > 0 {
+		return redact(bindData, names)
+	}
+	return bindData
+}
@@ -0,0 +1,143 @@
+package rx
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+/*
+RowPolicy returns a condition, which restricts the rows of a table, available
+for the operation `op` - one of `SELECT`, `UPDATE` and `DELETE`, and the named
+bind parameters, used in the condition. The context is the one of the model
+(see [Rx.WithContext]), so the current user can be taken from it. An empty
+condition means no restriction.
+*/
+type RowPolicy func(ctx context.Context, op string) (where string, bind map[string]any)
+
+// rowPolicies keeps the policies, set by SetRowPolicy, by table.
+var rowPolicies sync.Map
+
+/*
+SetRowPolicy sets the policy for `table`. Its condition is added with AND to
+the WHERE clause of every SELECT, UPDATE and DELETE, executed by [Rx] on the
+table, so access control is enforced in one place. This includes
+[Rx.UpdateBulk] and the source of [Rx.InsertFromSelect]. [Rx.Truncate],
+[Anonymize] and [Rx.DeleteCascade] for dependent tables, which can not be
+restricted, return [ErrRowPolicy] for the table. Pass nil to remove the policy.
+
+	rx.SetRowPolicy(`posts`, func(ctx context.Context, op string) (string, map[string]any) {
+		if op == `SELECT` {
+			return `published = 1 OR author_id = :rls_user`, map[string]any{`rls_user`: userID(ctx)}
+		}
+		return `author_id = :rls_user`, map[string]any{`rls_user`: userID(ctx)}
+	})
+
+Choose names for the bind parameters, which do not clash with the names of
+columns, because they take precedence over the bind data of the query.
+*/
+func SetRowPolicy(table string, policy RowPolicy) {
+	if policy == nil {
+		rowPolicies.Delete(table)
+		return
+	}
+	rowPolicies.Store(table, policy)
+}
+
+// whereTail are the clauses, which may follow the condition in a `where`,
+// passed to the methods of Rx.
+var whereTail = []string{`ORDER BY`, `GROUP BY`, `LIMIT`}
+
+/*
+where returns `where` with the keyword WHERE, restricted by the [RowPolicy] for
+the table of this instance, if there is one. The bind parameters of the policy
+are kept in policyBind, so they are merged into the bind data of the query.
+*/
+func (m *Rx[R]) where(op, where string) string {
+	return m.whereOn(m.Table(), op, where)
+}
+
+// whereOn is like where, but for the policy of `table`.
+func (m *Rx[R]) whereOn(table, op, where string) string {
+	m.policyBind = nil
+	extra, bind := m.policy(table, op)
+	if extra == `` {
+		return ifWhere(where)
+	}
+	m.policyBind = bind
+	condition, tail := isWhere.ReplaceAllString(where, ``), ``
+	if i := keywordIndex(condition, whereTail...); i >= 0 {
+		condition, tail = condition[:i], ` `+condition[i:]
+	}
+	if strings.TrimSpace(condition) == `` {
+		return sprintf(`WHERE (%s)%s`, extra, tail)
+	}
+	return sprintf(`WHERE (%s) AND (%s)%s`, strings.TrimSpace(condition), extra, tail)
+}
+
+// policy returns the condition of the [RowPolicy] for `table` and `op` and its
+// bind parameters. The condition is empty, if there is no policy.
+func (m *Rx[R]) policy(table, op string) (string, map[string]any) {
+	policy, ok := rowPolicies.Load(table)
+	if !ok {
+		return ``, nil
+	}
+	return policy.(RowPolicy)(m.ctx(), op)
+}
+
+/*
+andPolicy returns the condition of the [RowPolicy] for the table of this
+instance and `op` as ` AND (condition)` with positional bind parameters and
+their values, for queries, which are not executed with named parameters.
+*/
+func (m *Rx[R]) andPolicy(op string) (string, []any, error) {
+	extra, bind := m.policy(m.Table(), op)
+	if extra == `` {
+		return ``, nil, nil
+	}
+	q, args, err := sqlx.Named(extra, bind)
+	if err != nil {
+		return ``, nil, err
+	}
+	if q, args, err = sqlx.In(q, args...); err != nil {
+		return ``, nil, err
+	}
+	return ` AND (` + q + `)`, args, nil
+}
+
+/*
+withPolicyBind returns `bindData` with the bind parameters of the policy, if
+any, added. Structs are converted to maps with `mapper`, the same way, in which
+they would be bound.
+*/
+func (m *Rx[R]) withPolicyBind(bindData any, mapper *reflectx.Mapper) any {
+	if len(m.policyBind) == 0 {
+		return bindData
+	}
+	merged := Map{}
+	switch b := bindData.(type) {
+	case Map:
+		maps.Copy(merged, b)
+	case map[string]any:
+		maps.Copy(merged, b)
+	case nil:
+	default:
+		if v := reflect.Indirect(reflect.ValueOf(bindData)); v.Kind() == reflect.Struct {
+			for name, field := range mapper.FieldMap(v) {
+				merged[name] = field.Interface()
+			}
+		}
+	}
+	maps.Copy(merged, m.policyBind)
+	return merged
+}
+
+// namedMapper returns a mapper, like the one, used by [sqlx.Named].
+func namedMapper() *reflectx.Mapper {
+	return reflectx.NewMapperFunc(`db`, sqlx.NameMapper)
+}
@@ -0,0 +1,34 @@
+package rx
+
+import (
+	"reflect"
+	"sync"
+)
+
+// rowPools keeps a *sync.Pool of rows per type for AcquireRow and ReleaseRow.
+var rowPools sync.Map
+
+func rowPool[R Rowx]() *sync.Pool {
+	if p, ok := rowPools.Load(reflect.TypeFor[R]()); ok {
+		return p.(*sync.Pool)
+	}
+	p, _ := rowPools.LoadOrStore(reflect.TypeFor[R](), &sync.Pool{New: func() any { return new(R) }})
+	return p.(*sync.Pool)
+}
+
+/*
+AcquireRow returns a row with zero values from a pool of rows of type R. Pass
+it to [Rx.GetInto] and return it to the pool with [ReleaseRow], when it is not
+needed anymore.
+*/
+func AcquireRow[R Rowx]() *R {
+	return rowPool[R]().Get().(*R)
+}
+
+// ReleaseRow zeroes `row` and returns it to the pool, from which [AcquireRow]
+// takes rows. The row must not be used after that.
+func ReleaseRow[R Rowx](row *R) {
+	var zero R
+	*row = zero
+	rowPool[R]().Put(row)
+}
@@ -77,13 +77,21 @@ name. You can mark such fields with tags.
 package rx
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"iter"
+	"maps"
 	"os"
 	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/jmoiron/sqlx/reflectx"
@@ -92,17 +100,30 @@ import (
 )
 
 const (
-	// DefaultLimit is the default LIMIT for SQL queries.
-	DefaultLimit = 100
-	// DriverName is the name of the database engine to use. For now we only
-	// support `sqlite3`. Support for PostreSQL and MySQL is planned.
-	DriverName = `sqlite3`
 	// MigrationsTable is where we keep information about executed schema
 	// migrations.
 	MigrationsTable = `rx_migrations`
 )
 
 var (
+	// DriverName is the name of the database driver, used by [DB] to connect.
+	// The default is `sqlite3`. Other drivers (e.g. `duckdb`) must be
+	// imported by the application. Queries are specialized for the driver by
+	// the templates in [QueryTemplates] with suffix `_<DriverName>`. For
+	// PostgreSQL use `pgx` (github.com/jackc/pgx/v5/stdlib). Its prepared
+	// statement cache is configured in the DSN with
+	// `default_query_exec_mode` and `statement_cache_capacity`. Constraint
+	// violations are reported as typed errors like [ErrUniqueViolation].
+	DriverName = `sqlite3`
+	// DefaultLimit is the default LIMIT for SQL queries. It can be overridden
+	// per model with [Rx.WithDefaultLimit].
+	DefaultLimit = 100
+	// DefaultCapacity is the maximal initial capacity of the slices for the
+	// rows, selected by [Rx.Select], [Rx.SelectAll] and [Rx.Sample], so a
+	// lookup of one row does not allocate memory for [DefaultLimit] rows. The
+	// slices grow as needed. It can be overridden per model with
+	// [Rx.WithCapacityHint].
+	DefaultCapacity = 16
 	// DefaultLogHeader is a template for rx logging.
 	DefaultLogHeader = `${prefix}:${level}:${short_file}:${line}`
 	// DefaultLogOutput is where the output from the Logger will go to.
@@ -117,6 +138,96 @@ var (
 	// can change the log level as you wish. We use
 	// `github.com/labstack/gommon/log` as logging engine.
 	Logger = newLogger()
+	// SlowQueryThreshold enables logging of the query plan (see [Rx.Explain])
+	// for SELECT queries, which took longer than the threshold. Zero disables
+	// it.
+	SlowQueryThreshold time.Duration
+	// DefaultQueryTimeout limits the duration of every operation (query or
+	// set of queries for [Rx.InsertIDs], [Rx.Update]...). It can be
+	// overridden per model with [Rx.WithTimeout]. Zero (the default) means
+	// no limit. The operation fails with [context.DeadlineExceeded], when the
+	// limit is reached. [Rx.Rows] and [Rx.SelectIter] are limited only by the
+	// context, set with [Rx.WithContext], because the cursor outlives the
+	// call.
+	DefaultQueryTimeout time.Duration
+	// ExplainOnTimeout enables explaining queries, which failed with
+	// [context.DeadlineExceeded]. The query plan (see [Rx.Explain]) is
+	// attached to the returned [QueryError] as Plan. EXPLAIN is executed on
+	// a separate connection from the pool of the model (or [DB], if the
+	// model works in a transaction) and is limited by this duration. Zero
+	// (the default) disables it.
+	ExplainOnTimeout time.Duration
+	// TablePrefix is prepended to all table names, derived from type names,
+	// e.g. `app_`, when several applications share one database. [Generate]
+	// strips it from the names of the generated structs.
+	TablePrefix string
+	// TableSuffix is appended to all table names, derived from type names.
+	// [Generate] strips it from the names of the generated structs.
+	TableSuffix string
+	// AllowedRoots restricts the directories, from which [Migrate] and
+	// [SuggestDown] read migrations and in which [Generate] writes the model.
+	// Relative roots are resolved against the current working directory. If
+	// empty (the default), any path is allowed. Paths outside of the roots
+	// are rejected with [ErrUnsafePath].
+	AllowedRoots []string
+	// ErrUnsafePath is returned when a path is not within [AllowedRoots].
+	ErrUnsafePath = errors.New(`unsafe path`)
+	// ErrNoReturning is returned by [Rx.UpdateReturning] and
+	// [Rx.DeleteReturning] for databases, which do not support RETURNING.
+	ErrNoReturning = errors.New(`RETURNING is not supported`)
+	// ErrSchemaChanged is returned by [CheckFingerprint], when the schema of
+	// the database is not the one, the code was generated from.
+	ErrSchemaChanged = errors.New(`schema changed`)
+	// ErrNoTables is returned by [Generate] and the functions like it, when
+	// there are no tables to generate structures for - usually because of a
+	// mistyped DSN or table names.
+	ErrNoTables = errors.New(`no tables`)
+	// ErrNoDatabaseFile is returned by [RequireDatabaseFile], when the file
+	// of a SQLite or DuckDB database does not exist.
+	ErrNoDatabaseFile = errors.New(`database file does not exist`)
+	// ErrInvalidEnumValue is returned by the methods Value and Scan of the
+	// enum types, generated by [Generate], for values, which are not allowed
+	// by the CHECK constraint of the column.
+	ErrInvalidEnumValue = errors.New(`invalid enum value`)
+	// RequireTxForWrites makes the methods of [Rx], which write to the
+	// database (Insert, Update, Delete and their variants, Truncate and
+	// CopyFrom), fail with [ErrWriteOutsideTx], unless the model works in a
+	// transaction, set by [Rx.WithTx] or by the [Session] of [Transact] or
+	// [Model]. Enable it to enforce, that related writes are consistent.
+	// [Rx.InsertConcurrently] always fails then, because it can not be used
+	// in a transaction.
+	RequireTxForWrites bool
+	// ErrWriteOutsideTx is returned, when [RequireTxForWrites] is set and a
+	// model writes outside of a transaction.
+	ErrWriteOutsideTx = errors.New(`write outside of a transaction`)
+	// ReadOnly makes all methods of [Rx], which write to the database, and
+	// the functions [ResetAutoIncrement], [SetAutoIncrement] and
+	// [NextSequence] fail with [ErrReadOnly] without sending anything to the
+	// database. Set it, when working with replicas, for reporting or when
+	// inspecting a production database. Queries, executed directly on [DB],
+	// are not checked. For SQLite the connection itself can be made read-only
+	// by adding `_query_only=1` (or `mode=ro`) to the DSN.
+	ReadOnly bool
+	// ErrReadOnly is returned by write operations, when [ReadOnly] is set.
+	ErrReadOnly = errors.New(`read-only mode`)
+	// ErrNotFound is returned by [Rx.First] and [Rx.Last], when no row
+	// matches. It wraps [sql.ErrNoRows].
+	ErrNotFound = fmt.Errorf(`not found: %w`, sql.ErrNoRows)
+	// StrictWhere makes all methods, accepting a WHERE clause (and
+	// [Where] and [Having] clauses), reject it with [ErrUnsafeWhere], if it
+	// contains statement terminators (;) or comments (--, /* */) outside of
+	// quoted literals. Enable it as a defense in depth, when WHERE clauses
+	// are assembled from configuration. Values must still be passed as bind
+	// parameters.
+	StrictWhere bool
+	// ErrUnsafeWhere is returned, when a WHERE clause is rejected, because
+	// of [StrictWhere].
+	ErrUnsafeWhere = errors.New(`unsafe WHERE clause`)
+	// ErrRowPolicy is returned by [Rx.Truncate], [Anonymize] and
+	// [Rx.DeleteCascade] for tables with a [RowPolicy], because they can not
+	// be restricted by it, and by [Rx.UpdateBulk], if its template has no
+	// slot for the policy.
+	ErrRowPolicy = errors.New(`not allowed with a row policy`)
 	// ReflectXTag sets the tag name for identifying tags, read and acted upon
 	// by sqlx and Rx.
 	ReflectXTag = `rx`
@@ -148,21 +259,22 @@ func DB() *sqlx.DB {
 	if singleDB != nil {
 		return singleDB
 	}
-	Logger.Debugf("Connecting to database '%s'...", DSN)
+	Logger.Debugf("Connecting to database '%s'...", loggableDSN(DSN))
 
-	singleDB = sqlx.MustConnect(DriverName, DSN)
+	singleDB = sqlx.MustConnect(driverFor(DSN))
 	singleDB.Mapper = reflectx.NewMapperFunc(ReflectXTag, CamelToSnake)
 	return singleDB
 }
 
 /*
-ResetDB closes the connection to the database and undefines the underlying
-variable, holding the connection.
+ResetDB closes the statements, prepared by [Rx.Find], and the connection to the
+database and undefines the underlying variable, holding the connection.
 */
 func ResetDB() {
 	if singleDB == nil {
 		return
 	}
+	closeStmts(singleDB)
 	if err := singleDB.Close(); err != nil {
 		Logger.Errorf(`connection closed unsuccesfully: %s`, err.Error())
 	}
@@ -172,12 +284,18 @@ func ResetDB() {
 // Ext is a generic constraint for *sqlx.Tx and *sqlx.DB.
 type Ext interface {
 	sqlx.Ext
+	sqlx.ExtContext
 	PrepareNamed(query string) (*sqlx.NamedStmt, error)
+	PrepareNamedContext(ctx context.Context, query string) (*sqlx.NamedStmt, error)
 }
 
 /*
 Rx implements the [SqlxModel] interface and can be used right away or
 embedded (extended) to override some methods for a struct or set of structs.
+
+An instance of Rx is not safe for concurrent use. It stores the rows, retrieved
+or to be written, its table, columns and transaction. Use [Rx.Clone] to get an
+instance with the same configuration for every goroutine.
 */
 type Rx[R Rowx] struct {
 	// An instance of R which may implement the SqlxMeta interface (even only
@@ -197,6 +315,26 @@ type Rx[R Rowx] struct {
 	// columns of the table are populated upon first use of '.Columns()'.
 	columns []string
 	queryer Ext
+	// log overrides Logger for this instance, if not nil.
+	log *log.Logger
+	// limit overrides DefaultLimit for this instance, if not zero.
+	limit int
+	// capacity overrides DefaultCapacity for this instance, if not zero.
+	capacity int
+	// context is used for all queries of this instance, if not nil.
+	context context.Context
+	// timeout overrides DefaultQueryTimeout for this instance, if not zero.
+	timeout time.Duration
+	// query and bind are the query, executed by the current operation, and
+	// its bind data. They are reported in a QueryError, if it fails.
+	query string
+	bind  any
+	// args are the arguments of query, if it was prepared by
+	// namedInRebind. They are used to explain it, if it times out.
+	args []any
+	// policyBind are the bind parameters of the RowPolicy for the table,
+	// used by the current operation.
+	policyBind map[string]any
 }
 
 /*
@@ -208,6 +346,18 @@ func NewRx[R Rowx](rows ...R) SqlxModel[R] {
 	return &Rx[R]{data: rows, r: nilRowx[R]()}
 }
 
+/*
+Clone returns a new instance with the same configuration - table, columns,
+connection or transaction, logger and default limit, but without data. Use it
+to get a model per goroutine from a shared, preconfigured one. Note that
+[sqlx.Tx] is not safe for concurrent use either.
+*/
+func (m *Rx[R]) Clone() SqlxModel[R] {
+	return &Rx[R]{r: nilRowx[R](), table: m.table, columns: slices.Clone(m.columns),
+		queryer: m.queryer, log: m.log, limit: m.limit, capacity: m.capacity, context: m.context,
+		timeout: m.timeout}
+}
+
 // tX returns an *sqlx.DB or *sqlx.tX.
 func (m *Rx[R]) tX() Ext {
 	if m.queryer != nil {
@@ -216,14 +366,31 @@ func (m *Rx[R]) tX() Ext {
 	return DB()
 }
 
+// checkWrite returns [ErrReadOnly], if [ReadOnly] is set, or
+// [ErrWriteOutsideTx], if [RequireTxForWrites] is set and m does not work in a
+// transaction.
+func (m *Rx[R]) checkWrite() error {
+	if ReadOnly {
+		return fmt.Errorf(`%w: %s`, ErrReadOnly, m.Table())
+	}
+	if _, ok := m.queryer.(*sqlx.Tx); RequireTxForWrites && !ok {
+		return fmt.Errorf(`%w: %s`, ErrWriteOutsideTx, m.Table())
+	}
+	return nil
+}
+
 // Tx returns an *sqlx.Tx so you do not have to make type assertion when you
 // want to invoke *sqlx.Tx.Commit or *sqlx.Tx.Rollback. It creates a new one if
-// needed.
+// needed - on the connection, set by [WithDB], or on [DB].
 func (m *Rx[R]) Tx() *sqlx.Tx {
-	if m.queryer != nil {
-		return m.queryer.(*sqlx.Tx)
+	if tx, ok := m.queryer.(*sqlx.Tx); ok {
+		return tx
 	}
-	m.queryer = DB().MustBegin()
+	db, ok := m.queryer.(*sqlx.DB)
+	if !ok {
+		db = DB()
+	}
+	m.queryer = db.MustBeginTx(m.ctx(), nil)
 	return m.queryer.(*sqlx.Tx)
 }
 
@@ -234,6 +401,129 @@ func (m *Rx[R]) WithTx(queryer *sqlx.Tx) SqlxModel[R] {
 	return m
 }
 
+/*
+WithLogger sets a logger for this instance, overriding [Logger]. Use it to turn
+off the debug output in hot paths, without changing the level of [Logger] for
+the whole process. Pass nil to silence this instance completely.
+*/
+func (m *Rx[R]) WithLogger(l *log.Logger) SqlxModel[R] {
+	if l == nil {
+		l = log.New(ReflectXTag)
+		l.SetLevel(log.OFF)
+	}
+	m.log = l
+	return m
+}
+
+// logger returns the logger for this instance.
+func (m *Rx[R]) logger() *log.Logger {
+	if m.log != nil {
+		return m.log
+	}
+	return Logger
+}
+
+/*
+WithContext sets the context, used for all queries, executed by this instance.
+By default [context.Background] is used.
+*/
+func (m *Rx[R]) WithContext(ctx context.Context) SqlxModel[R] {
+	m.context = ctx
+	return m
+}
+
+// ctx returns the context for this instance.
+func (m *Rx[R]) ctx() context.Context {
+	if m.context != nil {
+		return m.context
+	}
+	return context.Background()
+}
+
+/*
+WithTimeout sets the maximum duration of every operation (query or set of
+queries), executed by this instance, overriding [DefaultQueryTimeout]. Pass a
+negative duration to not limit the operations at all.
+*/
+func (m *Rx[R]) WithTimeout(timeout time.Duration) SqlxModel[R] {
+	m.timeout = timeout
+	return m
+}
+
+// opCtx returns the context for one operation, limited by the timeout of
+// this instance or [DefaultQueryTimeout]. The operation is in flight for
+// [Close] until the returned function is called.
+func (m *Rx[R]) opCtx() (context.Context, context.CancelFunc) {
+	timeout := m.timeout
+	if timeout == 0 {
+		timeout = DefaultQueryTimeout
+	}
+	queryStarted()
+	if timeout <= 0 {
+		return m.ctx(), queryDone
+	}
+	ctx, cancel := context.WithTimeout(m.ctx(), timeout)
+	return ctx, func() {
+		cancel()
+		queryDone()
+	}
+}
+
+/*
+WithDefaultLimit sets the default LIMIT for SELECT queries, executed by this
+instance, overriding [DefaultLimit]. Pass [NoLimit] to not limit the result
+set at all.
+*/
+func (m *Rx[R]) WithDefaultLimit(limit int) SqlxModel[R] {
+	m.limit = limit
+	return m
+}
+
+func (m *Rx[R]) defaultLimit() int {
+	if m.limit != 0 {
+		return m.limit
+	}
+	return DefaultLimit
+}
+
+/*
+WithCapacityHint sets the initial capacity of the slices for the selected rows,
+overriding [DefaultCapacity]. Set it to the expected number of rows for large
+exports to avoid growing the slice many times. The capacity never exceeds the
+LIMIT of the query.
+*/
+func (m *Rx[R]) WithCapacityHint(n int) SqlxModel[R] {
+	m.capacity = n
+	return m
+}
+
+// rowsCapacity returns the initial capacity of the slice for the rows of a
+// query with `limit`. A limit, which is not positive, means no limit.
+func (m *Rx[R]) rowsCapacity(limit int) int {
+	capacity := m.capacity
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	if limit > 0 {
+		capacity = min(capacity, limit)
+	}
+	return max(capacity, 0)
+}
+
+/*
+NoLimit returns a value, which can be passed as LIMIT to [Rx.Select] or
+[Rx.WithDefaultLimit], to select all rows, matching the WHERE clause. Use it
+for exports and other cases, where silently truncating the result set is not
+acceptable.
+*/
+func NoLimit() int {
+	return noLimit
+}
+
+// noLimit is a negative LIMIT. With it the template `SELECT_ALL` is rendered
+// instead of `SELECT`.
+const noLimit = -1
+
 /*
 nilRowx returns a (*R)(nil). [Rx] uses it only for metadata extraction. So it
 does not need to allocate any memory. If a [Rowx] structure implements
@@ -258,33 +548,66 @@ func fieldsMap[R Rowx]() *reflectx.StructMap {
 	return DB().Mapper.TypeMap(reflect.ValueOf(nilRowx[R]()).Type())
 }
 
+// promotedMeta is returned by the methods of [SqlxMeta], promoted from an Rx,
+// embedded in an instance, returned by metaRow.
+const promotedMeta = "\x00promoted"
+
+/*
+metaRow returns an instance of R, on which the methods of [SqlxMeta] are called.
+If R embeds Rx[R], a new instance is returned, in which the embedded Rx is
+marked with promotedMeta. This way the methods, defined by R, are called, while
+the methods, promoted from Rx, return the mark immediately instead of
+recursing. Otherwise the instance is cached.
+*/
+func (m *Rx[R]) metaRow() *R {
+	if t := reflect.TypeFor[R](); t.Kind() == reflect.Struct {
+		for i := range t.NumField() {
+			if !t.Field(i).Anonymous {
+				continue
+			}
+			switch t.Field(i).Type {
+			case reflect.TypeFor[Rx[R]](), reflect.TypeFor[*Rx[R]]():
+				r := new(R)
+				mark := &Rx[R]{table: promotedMeta, columns: []string{promotedMeta}}
+				if f := reflect.ValueOf(r).Elem().Field(i); f.Kind() == reflect.Pointer {
+					f.Set(reflect.ValueOf(mark))
+				} else {
+					f.Set(reflect.ValueOf(mark).Elem())
+				}
+				return r
+			}
+		}
+	}
+	if m.r == nilRowx[R]() {
+		m.logger().Debugf("Instantiating %#v...", m.r)
+		m.r = new(R)
+	}
+	return m.r
+}
+
 /*
 Table returns the converted to snake_case name of the type to be used as table
-name in sql queries. If the underlying type implements the method Table from
-[SqlxMeta], the type is instantiated (if not already) and the method is called.
+name in sql queries, surrounded by [TablePrefix] and [TableSuffix]. If the
+underlying type implements the method Table from [SqlxMeta], the type is
+instantiated (if not already) and the method is called. This works also for
+types, which embed Rx and define their own Table method.
 */
 func (m *Rx[R]) Table() string {
 	if m.table != "" {
 		return m.table
 	}
-	/*
-		An implementing (at least partially) SqlxMeta type and not implementing
-		SqlxModel (Rowx(m.r).(SqlxModel[R]) == embeds Rx), because if the
-		underlying structure embeds Rx, we end up with stackoverflow (because
-		each next call enters this if, causing endelss recursion).
-	*/
-	if _, ok := Rowx(m.r).(SqlxModel[R]); !ok {
-		if _, ok = Rowx(m.r).(interface{ Table() string }); ok {
-			if m.r == nilRowx[R]() {
-				Logger.Debugf("Instantiating %#v...", m.r)
-				m.r = new(R)
-			}
-			Logger.Debugf(`m: %#+v`, m)
-			m.table = Rowx(m.r).(interface{ Table() string }).Table()
+	// An implementing (at least partially) SqlxMeta type. If it embeds Rx and
+	// does not define Table itself, the method is promoted from Rx and
+	// returns the mark, set by metaRow.
+	if _, ok := Rowx(m.r).(interface{ Table() string }); ok {
+		r := m.metaRow()
+		m.logger().Debugf("Getting table name from %T...", r)
+		if table := Rowx(r).(interface{ Table() string }).Table(); table != promotedMeta {
+			m.table = table
 			return m.table
 		}
 	}
-	m.table = TypeToSnake(nilRowx[R]())
+	m.table = TablePrefix + TypeToSnake(nilRowx[R]()) + TableSuffix
 	return m.table
 }
 
@@ -308,29 +631,27 @@ func (m *Rx[R]) SetData(data []R) SqlxModel[R] {
 /*
 Columns returns a slice with the names of the table's columns. If the underlying
 type implements the method Columns from [SqlxMeta], the type is instantiated
-(if not already) and the method is called.
+(if not already) and the method is called. Like [Rx.Table], this works also for
+types, which embed Rx.
 */
 func (m *Rx[R]) Columns() []string {
 	if len(m.columns) > 0 {
 		return m.columns
 	}
-	/*
-		An implementing (at least partially) SqlxMeta type and not implementing
-		SqlxModel (== embeds Rx), because if the underlying structure embeds
-		Rx, we end up with stackoverflow (because each next call enters this
-		"if" statement, causing endelss recursion).
-	*/
-	if _, ok := Rowx(m.r).(SqlxModel[R]); !ok {
-		if _, ok = Rowx(m.r).(interface{ Columns() []string }); ok {
-			if m.r == nilRowx[R]() {
-				Logger.Debugf("Instantiating %#v...", m.r)
-				m.r = new(R)
-			}
-			m.columns = Rowx(m.r).(interface{ Columns() []string }).Columns()
+	// The same as in Table.
+	if _, ok := Rowx(m.r).(interface{ Columns() []string }); ok {
+		columns := Rowx(m.metaRow()).(interface{ Columns() []string }).Columns()
+		if len(columns) != 1 || columns[0] != promotedMeta {
+			m.columns = columns
 			return m.columns
 		}
 	}
 
+	if cached, ok := columnsCache.Load(reflect.TypeFor[R]()); ok {
+		// Callers may modify the returned slice, so the cached one is copied.
+		m.columns = slices.Clone(cached.([]string))
+		return m.columns
+	}
 	colIndex := fieldsMap[R]().Index
 	m.columns = make([]string, 0, len(colIndex))
 	for _, v := range colIndex {
@@ -341,7 +662,7 @@ func (m *Rx[R]) Columns() []string {
 			continue
 		}
 		if _, exists := v.Options[`-`]; exists {
-			Logger.Debugf("Skipping field %s; Options %v", v.Field.Name, v.Options)
+			m.logger().Debugf("Skipping field %s; Options %v", v.Field.Name, v.Options)
 			continue
 		}
 		// Nested fields are not columns either. They are used for other purposes.
@@ -350,8 +671,8 @@ func (m *Rx[R]) Columns() []string {
 		}
 		m.columns = append(m.columns, v.Path)
 	}
-	Logger.Debugf(`columns: %#v`, m.columns)
-
+	m.logger().Debugf(`columns: %#v`, m.columns)
+	columnsCache.Store(reflect.TypeFor[R](), slices.Clone(m.columns))
 	return m.columns
 }
 
@@ -369,21 +690,343 @@ If you need to insert a [Rowx] structure with a specific value for ID, add a
 tag to the ID column `rx:"id,no_auto"` or use directly [sqlx].
 
 If you want to skip any field during insert (including `id`) add, a tag to it
-`rx:"field_name,auto"`.
+`rx:"field_name,auto"`. Fields, which are computed by the database, and are
+only selected, can be tagged as `rx:"field_name,readonly"`.
+
+Fields, tagged with a default value `rx:"status,default=active"`, which hold
+their zero value, are set to the default value before the insert, even if the
+column has no DEFAULT clause in the database.
+
+To skip, replace or update rows, which violate a UNIQUE constraint, use
+[Rx.InsertWith].
 */
 func (m *Rx[R]) Insert() (sql.Result, error) {
+	return m.InsertWith()
+}
+
+/*
+InsertWith inserts the rows like [Rx.Insert], modified by `opts`. Pass
+[OrIgnore] or [OrReplace] to skip or replace rows, which violate a UNIQUE
+constraint, e.g. for idempotent seeders and link tables. Pass [OrUpdate] to
+update them instead (upsert).
+
+	_, err := rx.NewRx(links...).InsertWith(rx.OrIgnore())
+*/
+func (m *Rx[R]) InsertWith(opts ...InsertOption) (_ sql.Result, err error) {
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`INSERT`, time.Now(), &err)
+	if err := m.checkWrite(); err != nil {
+		return nil, err
+	}
+	if len(m.Data()) == 0 {
+		m.logger().Panic("Cannot insert, when no data is provided!")
+	}
+	if err := m.fillDefaults(); err != nil {
+		return nil, err
+	}
+	query, err := m.renderInsertQuery(opts...)
+	if err != nil {
+		return nil, err
+	}
+	m.query, m.bind = query, m.Data()
+	m.logger().Debugf("Rendered query: %s", query)
+	m.logger().Debugf("Inserting rows: %+v", m.loggable(m.Data()))
+	if len(m.data) > 1 && (!multiRowValues.MatchString(query) ||
+		slices.Contains(batchInsertDrivers, m.tX().DriverName())) {
+		return m.insertEach(ctx, query)
+	}
+	r, err := sqlx.NamedExecContext(ctx, m.tX(), query, m.Data())
+	return r, dbError(err)
+}
+
+// multiRowValues matches queries, which sqlx can execute at once for many
+// rows, by repeating the group after VALUES.
+var multiRowValues = regexp.MustCompile(`\)\s*(?i)VALUES\s*\(`)
+
+// insertEach prepares `query` and executes it for every row in a transaction,
+// if it is not executed in one already, and returns the last result.
+func (m *Rx[R]) insertEach(ctx context.Context, query string) (r sql.Result, err error) {
+	ex := m.tX()
+	if db, ok := ex.(*sqlx.DB); ok {
+		tx, err := db.BeginTxx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		// The rollback will be ignored if the tx has been committed already.
+		defer func() { _ = tx.Rollback() }()
+		ex = tx
+	}
+	stmt, err := ex.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = stmt.Close() }()
+	for i := range m.data {
+		if r, err = stmt.ExecContext(ctx, &m.data[i]); err != nil {
+			return r, dbError(err)
+		}
+	}
+	if tx, ok := ex.(*sqlx.Tx); ok && tx != m.queryer {
+		err = tx.Commit()
+	}
+	return r, err
+}
+
+/*
+InsertConcurrently splits the rows into `workers` chunks and inserts every
+chunk like [Rx.Insert] in its own goroutine and transaction, for the throughput
+of ETL-style ingestion of very large batches on servers like PostgreSQL and
+MySQL. The insert is NOT all or nothing: if some chunks fail, the others are
+committed anyway. Returns the number of inserted rows and the errors of the
+failed chunks, joined. It can not be used in a transaction. `opts` are the
+same as for [Rx.InsertWith]. It panics if there is no data to be inserted.
+
+	n, err := rx.NewRx(rows...).InsertConcurrently(8)
+*/
+func (m *Rx[R]) InsertConcurrently(workers int, opts ...InsertOption) (int64, error) {
+	if len(m.Data()) == 0 {
+		m.logger().Panic("Cannot insert, when no data is provided!")
+	}
+	if err := m.checkWrite(); err != nil {
+		return 0, err
+	}
+	if _, ok := m.queryer.(*sqlx.Tx); ok {
+		return 0, fmt.Errorf(`insert in %s: rows can not be inserted concurrently in a transaction`, m.Table())
+	}
+	db, ok := m.queryer.(*sqlx.DB)
+	if !ok {
+		db = DB()
+	}
+	workers = max(1, min(workers, len(m.data)))
+	size := (len(m.data) + workers - 1) / workers
+	p := startProgress(`INSERT `+m.Table(), `rows`, int64(len(m.data)))
+	defer p.finish()
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		inserted int64
+		errs     []error
+	)
+	for chunk := range slices.Chunk(m.data, size) {
+		wg.Go(func() {
+			n, err := m.insertChunk(db, chunk, opts)
+			p.add(n)
+			mu.Lock()
+			defer mu.Unlock()
+			inserted += n
+			if err != nil {
+				errs = append(errs, err)
+			}
+		})
+	}
+	wg.Wait()
+	return inserted, errors.Join(errs...)
+}
+
+// insertChunk inserts `rows` in a new transaction on `db` and returns the
+// number of inserted rows.
+func (m *Rx[R]) insertChunk(db *sqlx.DB, rows []R, opts []InsertOption) (int64, error) {
+	tx, err := db.BeginTxx(m.ctx(), nil)
+	if err != nil {
+		return 0, err
+	}
+	// The rollback will be ignored if the tx has been committed already.
+	defer func() { _ = tx.Rollback() }()
+	r, err := m.Clone().WithTx(tx).SetData(rows).InsertWith(opts...)
+	if err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return r.RowsAffected()
+}
+
+/*
+InsertIDs inserts the rows like [Rx.Insert], but returns the values of the
+autoincremented `id` column for every inserted row in the order of the rows.
+Drivers, which do not support [sql.Result.LastInsertId] (e.g. PostgreSQL), get
+the values via `RETURNING id`, appended to the query from
+`INSERT_RETURNING_<driver>` in [QueryTemplates]. So the calling code does not
+have to branch per driver. Every row is inserted in its own statement. If the
+rows are more than one and no transaction was set with [Rx.WithTx], they are
+inserted in a new transaction. `opts` are the same as for [Rx.InsertWith].
+*/
+func (m *Rx[R]) InsertIDs(opts ...InsertOption) (ids []int64, err error) {
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`INSERT`, time.Now(), &err)
+	if err := m.checkWrite(); err != nil {
+		return nil, err
+	}
 	if len(m.Data()) == 0 {
-		Logger.Panic("Cannot insert, when no data is provided!")
+		m.logger().Panic("Cannot insert, when no data is provided!")
+	}
+	if err = m.fillDefaults(); err != nil {
+		return nil, err
+	}
+	ex := m.tX()
+	db, ownTx := ex.(*sqlx.DB)
+	if ownTx = ownTx && len(m.Data()) > 1; ownTx {
+		tx, err := db.BeginTxx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		// The rollback will be ignored if the tx has been committed already.
+		defer func() { _ = tx.Rollback() }()
+		ex = tx
+	}
+	returning, _ := QueryTemplates[dialectKey(`INSERT_RETURNING`, ex.DriverName())].(string)
+	query, err := m.renderInsertQuery(opts...)
+	if err != nil {
+		return nil, err
+	}
+	query += returning
+	m.query, m.bind = query, m.Data()
+	m.logger().Debugf("Rendered query: %s", query)
+	ids = make([]int64, 0, len(m.Data()))
+	for i := range m.data {
+		id, err := insertID(ctx, ex, query, &m.data[i], returning != ``)
+		if err != nil {
+			return ids, dbError(err)
+		}
+		ids = append(ids, id)
+	}
+	if ownTx {
+		err = ex.(*sqlx.Tx).Commit()
+	}
+	return ids, err
+}
+
+// fillDefaults sets the fields with tag option `default=value`, which hold
+// their zero value, to the default value in every row of data.
+func (m *Rx[R]) fillDefaults() error {
+	if reflect.TypeFor[R]().Kind() != reflect.Struct {
+		return nil
+	}
+	for _, fi := range fieldsMap[R]().Index {
+		value, ok := fi.Options[`default`]
+		if !ok {
+			continue
+		}
+		for i := range m.data {
+			field := reflectx.FieldByIndexes(reflect.ValueOf(&m.data[i]).Elem(), fi.Index)
+			if !field.IsZero() {
+				continue
+			}
+			if err := setDefault(field, value); err != nil {
+				return fmt.Errorf(`default value '%s' for %s: %w`, value, fi.Path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// setDefault parses `value` and sets it to `field`.
+func setDefault(field reflect.Value, value string) error {
+	if s, ok := field.Addr().Interface().(sql.Scanner); ok {
+		return s.Scan(value)
 	}
-	query := m.renderInsertQuery()
-	Logger.Debugf("Rendered query: %s", query)
-	Logger.Debugf("Inserting rows: %+v", m.Data())
-	return sqlx.NamedExec(m.tX(), query, m.Data())
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf(`unsupported type %s`, field.Type())
+	}
+	return nil
+}
+
+func insertID(ctx context.Context, ex Ext, query string, row any, returning bool) (id int64, err error) {
+	if !returning {
+		r, err := sqlx.NamedExecContext(ctx, ex, query, row)
+		if err != nil {
+			return 0, err
+		}
+		return r.LastInsertId()
+	}
+	rows, err := sqlx.NamedQueryContext(ctx, ex, query, row)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return 0, errors.Join(sql.ErrNoRows, rows.Err())
+	}
+	err = rows.Scan(&id)
+	return id, err
+}
+
+/*
+render renders the template `key` for the driver of this instance. If R has a
+method `Templates() Map` and the returned map contains `key` (or
+`key_<DriverName>`), its template is rendered instead of the one from
+[QueryTemplates].
+*/
+func (m *Rx[R]) render(key string, stash Map) (string, error) {
+	templates, k := m.templateKey(key)
+	return renderSQL(templates, k, stash)
 }
 
-func (m *Rx[R]) renderInsertQuery() string {
-	// TODO: Think of caching noAutoColumns (and use go:generate for all metadata)
-	noAutoColumns := make([]string, 0, len(m.Columns())-1)
+// templateKey returns the templates, in which the template `key` for the
+// driver of m is - the own ones of R or [QueryTemplates], and its key with
+// the suffix of the driver, if there is such a template.
+func (m *Rx[R]) templateKey(key string) (Map, string) {
+	driver := m.tX().DriverName()
+	if t, ok := Rowx(m.metaRow()).(interface{ Templates() Map }); ok {
+		own := t.Templates()
+		if k := dialectKeyIn(own, key, driver); own[k] != nil {
+			return own, k
+		}
+	}
+	return QueryTemplates, dialectKey(key, driver)
+}
+
+func (m *Rx[R]) renderInsertQuery(opts ...InsertOption) (string, error) {
+	parts := m.parts()
+	stash := map[string]any{
+		`columns`:      parts.insertList,
+		`table`:        m.Table(),
+		`placeholders`: parts.placeholders,
+	}
+	key := `INSERT`
+	for _, o := range opts {
+		key = o.key
+		if key == `INSERT_OR_UPDATE` {
+			upsertStash(stash, parts.insertColumns, o.keys)
+		}
+	}
+	return m.render(key, stash)
+}
+
+// insertColumns returns the columns, which values are inserted - all columns
+// without the ones with tag options `auto`, `readonly` or `expr`.
+func (m *Rx[R]) insertColumns() []string {
+	noAutoColumns := make([]string, 0, len(m.Columns()))
 	names := fieldsMap[R]().Names
 
 	for _, col := range m.Columns() {
@@ -395,7 +1038,7 @@ func (m *Rx[R]) renderInsertQuery() string {
 		// or modify the struct field accordingly, or add a tag to the struct
 		// field.
 		if !exists {
-			Logger.Warnf(`column %s not found in fieldsMap. This may lead to panic!`, col)
+			m.logger().Warnf(`column %s not found in fieldsMap. This may lead to panic!`, col)
 			noAutoColumns = append(noAutoColumns, col)
 			continue
 		}
@@ -404,24 +1047,77 @@ func (m *Rx[R]) renderInsertQuery() string {
 		if _, isNoAuto := colObj.Options[`no_auto`]; col == `id` && isNoAuto {
 			continue
 		}
-		// do not insert collumns with tag `auto`
-		if _, ok := colObj.Options[`auto`]; ok {
+		// do not insert collumns with tag `auto`, `readonly` or `expr`
+		if hasOption(colObj, `auto`, `readonly`, `expr`) {
 			continue
 		}
 		noAutoColumns = append(noAutoColumns, col)
 	}
-	placeholders := strings.Join(noAutoColumns, ",:") // :login_name,:changed_by...
-	placeholders = sprintf("(:%s)", placeholders)
-	// END TODO
-	stash := map[string]any{
-		`columns`: strings.Join(noAutoColumns, ","),
-		`table`:   m.Table(),
-		// TODO:
-		// `placeholders`: strings.TrimSuffix(strings.Repeat(placeholders+`,`, dataLen), `,`),
-		`placeholders`: placeholders,
+	return noAutoColumns
+}
+
+/*
+InsertFromSelect renders and executes `INSERT INTO dst (columns) SELECT
+src_columns FROM src WHERE ...`, where dst is the table of this instance and
+src is the table of `src`. It is useful for archiving and copying rows between
+tables without raw SQL. Any [SqlxModel] can be passed as `src`.
+
+`columnMap` maps destination columns to source columns or SQL expressions. If
+it is empty, all columns of this table, which also exist in `src`, are copied.
+The rows of `src` are restricted by its [RowPolicy] for SELECT, if any.
+*/
+func (m *Rx[R]) InsertFromSelect(srcWhere string, bindData any, src SqlxMeta[Rowx],
+	columnMap map[string]string) (_ sql.Result, err error) {
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`INSERT_FROM_SELECT`, time.Now(), &err)
+	if err := m.checkWrite(); err != nil {
+		return nil, err
+	}
+	if err := checkWhere(srcWhere); err != nil {
+		return nil, err
+	}
+	columns := make([]string, 0, len(m.Columns()))
+	srcColumns := make([]string, 0, len(m.Columns()))
+	if len(columnMap) == 0 {
+		names := fieldsMap[R]().Names
+		for _, col := range m.Columns() {
+			if fi, ok := names[col]; ok && hasOption(fi, `expr`) {
+				continue
+			}
+			if slices.Contains(src.Columns(), col) {
+				columns = append(columns, col)
+				srcColumns = append(srcColumns, col)
+			}
+		}
+	}
+	for _, col := range slices.Sorted(maps.Keys(columnMap)) {
+		columns = append(columns, col)
+		srcColumns = append(srcColumns, columnMap[col])
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf(`no common columns between %s and %s`, m.Table(), src.Table())
+	}
+	if bindData == nil {
+		bindData = map[string]any{}
+	}
+	query, err := m.render(`INSERT_FROM_SELECT`, Map{
+		`table`:       m.Table(),
+		`columns`:     strings.Join(columns, `,`),
+		`src_table`:   src.Table(),
+		`src_columns`: strings.Join(srcColumns, `,`),
+		`WHERE`:       m.whereOn(src.Table(), `SELECT`, srcWhere),
+	})
+	if err != nil {
+		return nil, err
 	}
-	query := RenderSQLTemplate(`INSERT`, stash)
-	return query
+	m.logger().Debugf("Rendered INSERT_FROM_SELECT query : %s", query)
+	q, args, err := m.namedInRebind(query, bindData)
+	if err != nil {
+		return nil, err
+	}
+	r, err := m.tX().ExecContext(ctx, q, args...)
+	return r, dbError(err)
 }
 
 /*
@@ -433,83 +1129,998 @@ as a slice. Selected records can also be used with [Rx.Data].
   - `bindData` can be a struct (even unnamed) or map[string]any.
   - `limitAndOffset` is expected to be used as a variadic parameter. If passed,
     it is expected to consist of two values limit and offset - in that order. The
-    default value for LIMIT can be set by [DefaultLimit]. OFFSET is 0 by default.
+    default value for LIMIT can be set by [DefaultLimit] or per instance by
+    [Rx.WithDefaultLimit]. Pass [NoLimit] to select all rows. OFFSET is 0 by
+    default and is ignored with [NoLimit].
+
+Fields, tagged as `rx:"full_name,expr=first_name || ' ' || last_name"`, are
+computed columns. They are selected as `(expression) AS full_name` and are
+never inserted or updated. The option `expr` must be the last one in the tag,
+so the expression may contain commas.
+
+If `where` has no `ORDER BY`, the rows are ordered by the fields, tagged with
+the option `defaultorder`, e.g. `rx:"created_at,defaultorder=desc"`, so they
+are listed in the same order on every database. [Rx.Get], [Rx.Rows],
+[Rx.SelectAll], [Rx.SelectIter], [Rx.First] and [Rx.Last] use it too.
 */
-func (m *Rx[R]) Select(where string, bindData any, limitAndOffset ...int) ([]R, error) {
-	if len(limitAndOffset) == 0 {
-		limitAndOffset = append(limitAndOffset, DefaultLimit)
-	}
-	if len(limitAndOffset) == 1 {
-		limitAndOffset = append(limitAndOffset, 0)
+func (m *Rx[R]) Select(where string, bindData any, limitAndOffset ...int) (_ []R, err error) {
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`SELECT`, time.Now(), &err)
+	if err := checkWhere(where); err != nil {
+		return nil, err
 	}
+	limitAndOffset = m.limitAndOffset(limitAndOffset)
 	if bindData == nil {
 		bindData = struct{}{}
 	}
-	query := m.renderSelectTemplate(where, limitAndOffset)
-	m.data = make([]R, 1, limitAndOffset[0])
+	query, err := m.renderSelectTemplate(where, limitAndOffset)
+	if err != nil {
+		return nil, err
+	}
+	m.data = make([]R, 0, m.rowsCapacity(limitAndOffset[0]))
+	defer m.explainIfSlow(`SELECT`, where, bindData, time.Now())
 
-	q, args, err := namedInRebind(query, bindData)
+	q, args, err := m.namedInRebind(query, bindData)
 	if err != nil {
 		return nil, err
 	}
-	return m.data, sqlx.Select(m.tX(), &m.data, q, args...)
+	return m.data, sqlx.SelectContext(ctx, m.tX(), &m.data, q, args...)
 }
 
-func (m *Rx[R]) renderSelectTemplate(where string, limitAndOffset []int) string {
-	stash := map[string]any{
-		`columns`: strings.Join(m.Columns(), ","),
-		`table`:   m.Table(),
-		`WHERE`:   ifWhere(where),
-		`limit`:   strconv.Itoa(limitAndOffset[0]),
-		`offset`:  strconv.Itoa(limitAndOffset[1]),
+// limitAndOffset fills in the default LIMIT and OFFSET, if not passed.
+func (m *Rx[R]) limitAndOffset(limitAndOffset []int) []int {
+	if len(limitAndOffset) == 0 {
+		limitAndOffset = append(limitAndOffset, m.defaultLimit())
+	}
+	if len(limitAndOffset) == 1 {
+		limitAndOffset = append(limitAndOffset, 0)
 	}
-	query := RenderSQLTemplate(`SELECT`, stash)
-	Logger.Debugf("Rendered SELECT query : %s", query)
-	return query
+	return limitAndOffset
 }
 
 /*
-Get executes [sqlx.DB.Get] and returns the result scanned into an instantiated
-[Rowx] object or an error.
+Rows executes the same SELECT query as [Rx.Select], but instead of scanning
+the result set, it returns the underlying cursor. Use it for custom scanning -
+partial structures, maps, streaming encoders etc. The caller must close the
+returned [sqlx.Rows]. The query is executed in the transaction of this
+instance if there is one.
 */
-func (m *Rx[R]) Get(where string, bindData ...any) (*R, error) {
-	query := m.renderSelectTemplate(where, []int{1, 0})
-	var (
-		q    string
-		args []any
-		err  error
-	)
-	if len(bindData) == 0 {
-		bindData = append(bindData, struct{}{})
+func (m *Rx[R]) Rows(where string, bindData any, limitAndOffset ...int) (_ *sqlx.Rows, err error) {
+	defer m.track(`SELECT`, time.Now(), &err)
+	if err := checkWhere(where); err != nil {
+		return nil, err
+	}
+	limitAndOffset = m.limitAndOffset(limitAndOffset)
+	if bindData == nil {
+		bindData = struct{}{}
 	}
-	q, args, err = namedInRebind(query, bindData[0])
+	query, err := m.renderSelectTemplate(where, limitAndOffset)
 	if err != nil {
-		return nilRowx[R](), err
+		return nil, err
 	}
-	m.r = new(R)
-	return m.r, sqlx.Get(m.tX(), m.r, q, args...)
-}
-
-var isWhere = regexp.MustCompile(`(?i:^\s*?where\s)`)
-
-func ifWhere(where string) string {
-	if where != `` && !isWhere.MatchString(where) {
-		where = sprintf(`WHERE %s`, where)
+	q, args, err := m.namedInRebind(query, bindData)
+	if err != nil {
+		return nil, err
 	}
-	return where
+	return m.tX().QueryxContext(m.ctx(), q, args...)
 }
 
-func namedInRebind(query string, bindData any) (string, []any, error) {
-	q, args, err := sqlx.Named(query, bindData)
-	if err != nil {
+/*
+SelectAll is like [Rx.Select], but renders no LIMIT and OFFSET at all and
+returns all rows, matching the `where` clause. It is intended for batch jobs,
+which must see every row. For big tables consider [Rx.SelectIter].
+*/
+func (m *Rx[R]) SelectAll(where string, bindData any) (_ []R, err error) {
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`SELECT_ALL`, time.Now(), &err)
+	q, args, err := m.renderSelectAll(where, bindData)
+	if err != nil {
+		return nil, err
+	}
+	m.data = make([]R, 0, m.rowsCapacity(0))
+	return m.data, sqlx.SelectContext(ctx, m.tX(), &m.data, q, args...)
+}
+
+/*
+Sample returns up to `n` random rows, matching `where`. It is useful for spot
+checks of data and A/B sampling. The whole matching set is sorted randomly by
+the database, so narrow it down with `where` on big tables.
+*/
+func (m *Rx[R]) Sample(n int, where string, bindData any) (_ []R, err error) {
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`SAMPLE`, time.Now(), &err)
+	if err := checkWhere(where); err != nil {
+		return nil, err
+	}
+	if bindData == nil {
+		bindData = struct{}{}
+	}
+	query, err := m.render(`SAMPLE`, Map{
+		`columns`: m.parts().selectColumns,
+		`table`:   m.Table(),
+		`WHERE`:   m.where(`SELECT`, where),
+		`limit`:   strconv.Itoa(n),
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.logger().Debugf("Rendered SAMPLE query : %s", query)
+	q, args, err := m.namedInRebind(query, bindData)
+	if err != nil {
+		return nil, err
+	}
+	m.data = make([]R, 0, m.rowsCapacity(n))
+	return m.data, sqlx.SelectContext(ctx, m.tX(), &m.data, q, args...)
+}
+
+/*
+SelectKeyed executes [SqlxSelectorExt.SelectAll] on `m` and returns the rows in a
+map by the values of `keyColumn` - a lookup table. Methods cannot have type
+parameters, so this is a function. The order of the rows is kept in
+[SqlxModel.Data]. If several rows have the same key, the last one is in the
+map. Integer and float columns can be mapped to any integer or float type K.
+
+	groups, err := rx.SelectKeyed[int64](rx.NewRx[Groups](), `id`, ``, nil)
+*/
+func SelectKeyed[K comparable, R Rowx](m SqlxModel[R], keyColumn, where string, bindData any) (map[K]R, error) {
+	rows, err := m.SelectAll(where, bindData)
+	if err != nil {
+		return nil, err
+	}
+	keyType := reflect.TypeFor[K]()
+	keyed := make(map[K]R, len(rows))
+	for i := range rows {
+		value, err := fieldValue(&rows[i], keyColumn)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := value.(K)
+		if !ok {
+			v := reflect.ValueOf(value)
+			if !isNumber(v.Kind()) || !isNumber(keyType.Kind()) {
+				return nil, fmt.Errorf(`cannot use %T value of %s as %s key`, value, keyColumn, keyType)
+			}
+			key = v.Convert(keyType).Interface().(K)
+		}
+		keyed[key] = rows[i]
+	}
+	return keyed, nil
+}
+
+/*
+ExistingKeys reports which of `keys` exist in `keyColumn` of the table of `m`
+with one `IN` query - every key is in the returned map with true, if a row has
+it. Use it before bulk upserts, when synchronizing external data. The
+[RowPolicy] of the table applies. Methods cannot have type parameters, so this
+is a function.
+
+	exist, err := rx.ExistingKeys(rx.NewRx[Users](), []string{`foo`, `bar`}, `login_name`)
+*/
+func ExistingKeys[K comparable, R Rowx](m SqlxModel[R], keys []K, keyColumn string) (map[K]bool, error) {
+	exist := make(map[K]bool, len(keys))
+	if len(keys) == 0 {
+		return exist, nil
+	}
+	var found []K
+	err := m.SelectGrouped(&found, Map{`keys`: keys},
+		Where(sprintf(`%s IN(:keys)`, keyColumn)), GroupBy(keyColumn))
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		exist[k] = false
+	}
+	for _, k := range found {
+		exist[k] = true
+	}
+	return exist, nil
+}
+
+func isNumber(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
+
+/*
+SelectIter returns an iterator over all rows, matching the `where` clause. No
+LIMIT is rendered. The rows are scanned one by one while iterating, so the
+whole result set is never kept in memory. On error the iterator yields the
+error with a zero value of R and stops. Like [Rx.Rows], it is limited only by
+the context, set with [Rx.WithContext], and not by [DefaultQueryTimeout],
+because the caller decides how long the iteration takes.
+
+	for u, err := range rx.NewRx[Users]().SelectIter(`group_id=:id`, rx.Map{`id`: 1}) {
+		if err != nil {
+			return err
+		}
+		// do something with u
+	}
+*/
+func (m *Rx[R]) SelectIter(where string, bindData any) iter.Seq2[R, error] {
+	return func(yield func(R, error) bool) {
+		queryStarted()
+		defer queryDone()
+		start := time.Now()
+		var row R
+		q, args, err := m.renderSelectAll(where, bindData)
+		defer m.track(`SELECT_ALL`, start, &err)
+		if err != nil {
+			err = m.queryError(`SELECT_ALL`, err)
+			yield(row, err)
+			return
+		}
+		rows, err := m.tX().QueryxContext(m.ctx(), q, args...)
+		if err != nil {
+			err = m.queryError(`SELECT_ALL`, err)
+			yield(row, err)
+			return
+		}
+		defer func() { _ = rows.Close() }()
+		for rows.Next() {
+			row = *new(R)
+			if err = rows.StructScan(&row); err != nil {
+				err = m.queryError(`SELECT_ALL`, err)
+				yield(row, err)
+				return
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+		if err = rows.Err(); err != nil {
+			err = m.queryError(`SELECT_ALL`, err)
+			yield(*new(R), err)
+		}
+	}
+}
+
+func (m *Rx[R]) renderSelectAll(where string, bindData any) (string, []any, error) {
+	if err := checkWhere(where); err != nil {
+		return ``, nil, err
+	}
+	if bindData == nil {
+		bindData = struct{}{}
+	}
+	query, err := m.renderSelect(`SELECT_ALL`, where, Map{
+		`columns`: m.parts().selectColumns,
+		`table`:   m.Table(),
+	})
+	if err != nil {
+		return ``, nil, err
+	}
+	m.logger().Debugf("Rendered SELECT_ALL query : %s", query)
+	return m.namedInRebind(query, bindData)
+}
+
+/*
+Page is a page of rows, returned by [Rx.SelectWithCount], together with the
+total number of rows, matching the WHERE clause.
+*/
+type Page[R Rowx] struct {
+	Rows    []R
+	Total   int64
+	Limit   int
+	Offset  int
+	HasNext bool
+}
+
+/*
+SelectWithCount executes [Rx.Select] with the given `limit` and `offset` and a
+second `SELECT COUNT(*)` query with the same WHERE clause and `bindData`. It
+returns a [Page] with the selected rows, the total count of matching rows and
+whether there are more rows after this page.
+*/
+func (m *Rx[R]) SelectWithCount(where string, bindData any, limit, offset int) (Page[R], error) {
+	page := Page[R]{Limit: limit, Offset: offset}
+	rows, err := m.Select(where, bindData, limit, offset)
+	if err != nil {
+		return page, err
+	}
+	page.Rows = rows
+	if page.Total, err = m.count(where, bindData); err != nil {
+		return page, err
+	}
+	page.HasNext = int64(offset+len(rows)) < page.Total
+	return page, nil
+}
+
+func (m *Rx[R]) count(where string, bindData any) (total int64, err error) {
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`COUNT`, time.Now(), &err)
+	if err = checkWhere(where); err != nil {
+		return 0, err
+	}
+	if bindData == nil {
+		bindData = struct{}{}
+	}
+	// ORDER BY is not needed for counting and is not allowed by every database.
+	where, _, err = m.orderedWhere(where)
+	if err != nil {
+		return 0, err
+	}
+	query, err := m.render(`COUNT`, Map{`table`: m.Table(), `WHERE`: where})
+	if err != nil {
+		return 0, err
+	}
+	m.logger().Debugf("Rendered COUNT query : %s", query)
+	q, args, err := m.namedInRebind(query, bindData)
+	if err != nil {
+		return total, err
+	}
+	return total, sqlx.GetContext(ctx, m.tX(), &total, q, args...)
+}
+
+/*
+SelectGrouped executes a grouped aggregate SELECT from the table of R and
+scans the result into `dest`, which must be a pointer to a slice of
+caller-provided structures. The selected columns are the ones from the
+[GroupBy] clause, followed by the expressions from [Aggregate] clauses.
+
+	var dest []struct {
+		GroupID int64
+		Users   int64
+	}
+	err := rx.NewRx[Users]().SelectGrouped(&dest, rx.Map{`n`: 1},
+		rx.GroupBy(`group_id`), rx.Aggregate(`COUNT(*) AS users`),
+		rx.Having(`COUNT(*) > :n`))
+*/
+func (m *Rx[R]) SelectGrouped(dest any, bindData any, clauses ...Clause) (err error) {
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`SELECT_GROUPED`, time.Now(), &err)
+	stash := Map{`table`: m.Table(), `WHERE`: ``, `GROUP_BY`: ``, `HAVING`: ``, `ORDER_BY`: ``}
+	columns := make([]string, 0, len(clauses))
+	for _, c := range clauses {
+		if c.key == `WHERE` || c.key == `HAVING` {
+			if err := checkWhere(c.sql); err != nil {
+				return err
+			}
+		}
+		if c.collation != `` {
+			expr, err := m.Collate(c.sql, c.collation)
+			if err != nil {
+				return err
+			}
+			c.sql = `ORDER BY ` + expr
+		}
+		if c.key == `ORDER_BY` && stash[`ORDER_BY`] != `` {
+			c.sql = stash[`ORDER_BY`].(string) + `,` + strings.TrimPrefix(c.sql, `ORDER BY `)
+		}
+		if c.key != `` {
+			stash[c.key] = c.sql
+		}
+		columns = append(columns, c.columns...)
+	}
+	if len(columns) == 0 {
+		return errors.New(`no columns to select: use rx.GroupBy or rx.Aggregate`)
+	}
+	stash[`columns`] = strings.Join(columns, `,`)
+	stash[`WHERE`] = m.where(`SELECT`, stash[`WHERE`].(string))
+	if bindData == nil {
+		bindData = struct{}{}
+	}
+	query, err := m.render(`SELECT_GROUPED`, stash)
+	if err != nil {
+		return err
+	}
+	m.logger().Debugf("Rendered SELECT_GROUPED query : %s", query)
+	q, args, err := m.namedInRebind(query, bindData)
+	if err != nil {
+		return err
+	}
+	return sqlx.SelectContext(ctx, m.tX(), dest, q, args...)
+}
+
+func (m *Rx[R]) renderSelectTemplate(where string, limitAndOffset []int) (string, error) {
+	stash := map[string]any{
+		`columns`: m.parts().selectColumns,
+		`table`:   m.Table(),
+		`limit`:   strconv.Itoa(limitAndOffset[0]),
+		`offset`:  strconv.Itoa(limitAndOffset[1]),
+	}
+	// Not every database understands a negative LIMIT, so no LIMIT is rendered.
+	key := `SELECT`
+	if limitAndOffset[0] < 0 {
+		key = `SELECT_ALL`
+	}
+	query, err := m.renderSelect(key, where, stash)
+	m.logger().Debugf("Rendered %s query : %s", key, query)
+	return query, err
+}
+
+/*
+renderSelect renders the template `key` with `where`, split into the slots WHERE
+and ORDER_BY by [Rx.orderedWhere]. If there is no order, ORDER_BY is the
+template `NO_ORDER_BY`. Own templates of R (see [SqlxMeta]) without the slot
+ORDER_BY get the order in WHERE, as before the slot was introduced.
+*/
+func (m *Rx[R]) renderSelect(key, where string, stash Map) (string, error) {
+	where, orderBy, err := m.orderedWhere(where)
+	if err != nil {
+		return ``, err
+	}
+	templates, k := m.templateKey(key)
+	if tpl, _ := templates[k].(string); !strings.Contains(tpl, `${ORDER_BY}`) {
+		where, orderBy = strings.TrimSpace(where+` `+orderBy), ``
+	} else if orderBy == `` {
+		if orderBy, err = m.render(`NO_ORDER_BY`, nil); err != nil {
+			return ``, err
+		}
+	}
+	stash[`WHERE`], stash[`ORDER_BY`] = where, orderBy
+	return renderSQL(templates, k, stash)
+}
+
+/*
+orderedWhere splits `where`, prepared by [Rx.where], into the condition with
+the keyword WHERE (and GROUP BY and HAVING, if any) and the `ORDER BY` clause
+with the clauses after it. If `where` has no `ORDER BY`, the default one of R
+is used and is put before LIMIT, if `where` has it.
+*/
+func (m *Rx[R]) orderedWhere(where string) (_, orderBy string, _ error) {
+	p := m.parts()
+	if p.orderErr != nil {
+		return ``, ``, p.orderErr
+	}
+	where = m.where(`SELECT`, where)
+	if i := keywordIndex(where, `ORDER BY`); i >= 0 {
+		where, orderBy = where[:i], where[i:]
+	} else if p.orderBy != `` {
+		orderBy = p.orderBy
+		if i := keywordIndex(where, `LIMIT`, `OFFSET`, `FETCH`); i >= 0 {
+			where, orderBy = where[:i], orderBy+` `+where[i:]
+		}
+	}
+	return strings.TrimSpace(where), orderBy, nil
+}
+
+/*
+defaultOrder returns the `ORDER BY` clause, declared by the fields of R with
+the tag option `defaultorder`, e.g. `rx:"created_at,defaultorder=desc"`. The
+value is `asc` (the default) or `desc`. The fields are ordered by in the order,
+they are declared.
+*/
+func defaultOrder[R Rowx]() (string, error) {
+	if reflect.TypeFor[R]().Kind() != reflect.Struct {
+		return ``, nil
+	}
+	var order []string
+	for _, fi := range fieldsMap[R]().Index {
+		direction, ok := fi.Options[`defaultorder`]
+		if !ok || strings.Contains(fi.Path, `.`) {
+			continue
+		}
+		switch strings.ToUpper(direction) {
+		case ``, `ASC`:
+			order = append(order, fi.Path)
+		case `DESC`:
+			order = append(order, fi.Path+` DESC`)
+		default:
+			return ``, fmt.Errorf(`defaultorder for %s must be asc or desc, not '%s'`, fi.Path, direction)
+		}
+	}
+	if len(order) == 0 {
+		return ``, nil
+	}
+	return `ORDER BY ` + strings.Join(order, `,`), nil
+}
+
+/*
+Get executes [sqlx.DB.Get] and returns the result scanned into an instantiated
+[Rowx] object or an error.
+*/
+func (m *Rx[R]) Get(where string, bindData ...any) (*R, error) {
+	m.r = new(R)
+	queried, err := m.getInto(m.r, where, bindData)
+	if !queried {
+		return nil, err
+	}
+	return m.r, err
+}
+
+/*
+GetInto is like [Rx.Get], but scans the row into `dest`, so no row is allocated.
+Together with [AcquireRow] and [ReleaseRow] it takes the pressure off the
+garbage collector in hot paths with millions of lookups.
+
+	user := rx.AcquireRow[Users]()
+	defer rx.ReleaseRow(user)
+	err := m.GetInto(user, `id = :id`, rx.Map{`id`: id})
+*/
+func (m *Rx[R]) GetInto(dest *R, where string, bindData ...any) error {
+	_, err := m.getInto(dest, where, bindData)
+	return err
+}
+
+// getInto executes GetInto and reports if the query was executed.
+func (m *Rx[R]) getInto(dest *R, where string, bindData []any) (_ bool, err error) {
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`GET`, time.Now(), &err)
+	if err := checkWhere(where); err != nil {
+		return false, err
+	}
+	query, err := m.renderSelectTemplate(where, []int{1, 0})
+	if err != nil {
+		return false, err
+	}
+	var (
+		q    string
+		args []any
+	)
+	if len(bindData) == 0 {
+		bindData = append(bindData, struct{}{})
+	}
+	q, args, err = m.namedInRebind(query, bindData[0])
+	if err != nil {
+		return false, err
+	}
+	defer m.explainIfSlow(`GET`, where, bindData[0], time.Now())
+	return true, sqlx.GetContext(ctx, m.tX(), dest, q, args...)
+}
+
+/*
+First returns the first row, matching `where`, ordered by `orderBy` - columns,
+optionally followed by ASC or DESC. If `orderBy` is empty, the rows are ordered
+by the fields, tagged with the option `defaultorder` (see [Rx.Select]), or else
+by the primary key (see [Rx.FindMany]). If no row matches, [ErrNotFound] is
+returned.
+
+	latest, err := rx.NewRx[Users]().First(`group_id=:g`, rx.Map{`g`: 1}, `id DESC`)
+*/
+func (m *Rx[R]) First(where string, bindData any, orderBy ...string) (*R, error) {
+	return m.first(where, bindData, orderBy, false)
+}
+
+/*
+Last returns the last row, matching `where`, ordered by `orderBy` - the same as
+[Rx.First], but with reversed order.
+*/
+func (m *Rx[R]) Last(where string, bindData any, orderBy ...string) (*R, error) {
+	return m.first(where, bindData, orderBy, true)
+}
+
+func (m *Rx[R]) first(where string, bindData any, orderBy []string, reverse bool) (_ *R, err error) {
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`FIRST`, time.Now(), &err)
+	if err := checkWhere(where); err != nil {
+		return nil, err
+	}
+	if len(orderBy) == 0 {
+		if orderBy, err = m.defaultOrderBy(); err != nil {
+			return nil, err
+		}
+	}
+	if reverse {
+		orderBy = reverseOrder(orderBy)
+	}
+	if bindData == nil {
+		bindData = struct{}{}
+	}
+	stash := Map{`columns`: m.parts().selectColumns, `table`: m.Table(), `WHERE`: m.where(`SELECT`, where), `ORDER_BY`: ``}
+	if len(orderBy) > 0 {
+		stash[`ORDER_BY`] = `ORDER BY ` + strings.Join(orderBy, `,`)
+	}
+	query, err := m.render(`FIRST`, stash)
+	if err != nil {
+		return nil, err
+	}
+	m.logger().Debugf("Rendered FIRST query : %s", query)
+	q, args, err := m.namedInRebind(query, bindData)
+	if err != nil {
+		return nil, err
+	}
+	row := new(R)
+	if err = sqlx.GetContext(ctx, m.tX(), row, q, args...); errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return row, err
+}
+
+// defaultOrderBy returns the columns of the default order of R (see
+// [Rx.Select]) or, if there is none, the primary key columns.
+func (m *Rx[R]) defaultOrderBy() ([]string, error) {
+	p := m.parts()
+	if p.orderErr != nil {
+		return nil, p.orderErr
+	}
+	if p.orderBy == `` {
+		return m.pkColumns(), nil
+	}
+	return strings.Split(strings.TrimPrefix(p.orderBy, `ORDER BY `), `,`), nil
+}
+
+/*
+Collate returns `column COLLATE collation`, rendered with the `COLLATE` template
+from [QueryTemplates] for the driver of m, so the collation is quoted as the
+database expects. A trailing ASC or DESC in `column` is moved after the
+collation. Use it for ordering of non-ASCII text in [Rx.First], [Rx.Last] and
+in WHERE clauses. See also [OrderByCollate].
+
+	byName, err := m.Collate(`first_name`, `bg-x-icu`)
+	// ...
+	first, err := m.First(`1=1`, nil, byName)
+*/
+func (m *Rx[R]) Collate(column, collation string) (string, error) {
+	direction := ``
+	if fields := strings.Fields(column); len(fields) > 1 {
+		last := fields[len(fields)-1]
+		if strings.EqualFold(last, `ASC`) || strings.EqualFold(last, `DESC`) {
+			column, direction = strings.Join(fields[:len(fields)-1], ` `), ` `+last
+		}
+	}
+	expr, err := m.render(`COLLATE`, Map{`column`: column, `collation`: collation})
+	if err != nil {
+		return ``, err
+	}
+	return expr + direction, nil
+}
+
+// reverseOrder swaps ASC and DESC in the `ORDER BY` expressions.
+func reverseOrder(orderBy []string) []string {
+	reversed := make([]string, len(orderBy))
+	for i, o := range orderBy {
+		fields := strings.Fields(o)
+		switch last := len(fields) - 1; {
+		case last > 0 && strings.EqualFold(fields[last], `DESC`):
+			fields[last] = `ASC`
+		case last > 0 && strings.EqualFold(fields[last], `ASC`):
+			fields[last] = `DESC`
+		default:
+			fields = append(fields, `DESC`)
+		}
+		reversed[i] = strings.Join(fields, ` `)
+	}
+	return reversed
+}
+
+/*
+FindMany returns the rows with the given primary keys in the order of `pks`.
+Missing and repeated keys are skipped. The primary key columns are the fields,
+tagged with the option `pk` - `rx:"code,pk"`, or the column `id`. For a
+composite primary key every element of `pks` must be a []any with the values
+in the order of the fields.
+
+	users, err := rx.NewRx[Users]().FindMany(3, 1, 2)
+	links, err := rx.NewRx[UserGroup]().FindMany([]any{1, 2}, []any{3, 1})
+*/
+func (m *Rx[R]) FindMany(pks ...any) ([]R, error) {
+	columns := m.pkColumns()
+	if len(columns) == 0 {
+		return nil, fmt.Errorf(`no primary key column for %s: tag a field as pk`, m.Table())
+	}
+	if len(pks) == 0 {
+		m.data = []R{}
+		return m.data, nil
+	}
+	bind := Map{`pks`: pks}
+	where := sprintf(`%s IN(:pks)`, columns[0])
+	if len(columns) > 1 {
+		bind = Map{}
+		tuples := make([]string, len(pks))
+		for i, pk := range pks {
+			values, ok := pk.([]any)
+			if !ok || len(values) != len(columns) {
+				return nil, fmt.Errorf(`primary key %d must be []any with %d values, but it is %#v`,
+					i, len(columns), pk)
+			}
+			names := make([]string, len(values))
+			for j, v := range values {
+				names[j] = sprintf(`pk%d_%d`, i, j)
+				bind[names[j]] = v
+			}
+			tuples[i] = `(:` + strings.Join(names, `,:`) + `)`
+		}
+		where = sprintf(`(%s) IN(%s)`, strings.Join(columns, `,`), strings.Join(tuples, `,`))
+	}
+	rows, err := m.SelectAll(where, bind)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]int, len(rows))
+	for i := range rows {
+		values := make([]any, len(columns))
+		for j, c := range columns {
+			if values[j], err = fieldValue(&rows[i], c); err != nil {
+				return nil, err
+			}
+		}
+		byKey[pkKey(values)] = i
+	}
+	m.data = make([]R, 0, len(rows))
+	for _, pk := range pks {
+		values, ok := pk.([]any)
+		if !ok {
+			values = []any{pk}
+		}
+		key := pkKey(values)
+		if i, found := byKey[key]; found {
+			m.data = append(m.data, rows[i])
+			delete(byKey, key)
+		}
+	}
+	return m.data, nil
+}
+
+// pkKey returns a key for comparing primary key values of different types.
+// The values are converted as for the driver, so an int and an int64, a string
+// and a [sql.NullString] or a [driver.Valuer] are equal. Every value is quoted,
+// so the values of a composite key can not run into each other.
+func pkKey(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		if cv, err := driver.DefaultParameterConverter.ConvertValue(v); err == nil {
+			v = cv
+		}
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		parts[i] = strconv.Quote(fmt.Sprint(v))
+	}
+	return strings.Join(parts, `,`)
+}
+
+/*
+Find returns the row with the primary key `pk` - the most frequent query. For a
+composite primary key pass the values in the order of the fields (see
+[Rx.FindMany]). If no row matches, [ErrNotFound] is returned.
+
+The statement is prepared once per connection, type, table and columns and
+reused, so no template is rendered and no named parameters are bound. Instances
+in a transaction, types with own templates (see [SqlxMeta]) and tables with a
+[RowPolicy] fall back to [Rx.Get].
+
+	user, err := rx.NewRx[Users]().Find(3)
+*/
+func (m *Rx[R]) Find(pk ...any) (_ *R, err error) {
+	columns := m.pkColumns()
+	if len(columns) == 0 {
+		return nil, fmt.Errorf(`no primary key column for %s: tag a field as pk`, m.Table())
+	}
+	if len(pk) != len(columns) {
+		return nil, fmt.Errorf(`%d primary key values are needed for %s, but %d were passed`,
+			len(columns), m.Table(), len(pk))
+	}
+	db, parts, prepared := m.preparedFind()
+	if !prepared {
+		bind := Map{}
+		where := make([]string, len(columns))
+		for i, c := range columns {
+			where[i] = sprintf(`%s = :%[1]s`, c)
+			bind[c] = pk[i]
+		}
+		row := new(R)
+		if _, err := m.getInto(row, strings.Join(where, ` AND `), []any{bind}); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrNotFound
+			}
+			return nil, err
+		}
+		return row, nil
+	}
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`FIND`, time.Now(), &err)
+	find, err := parts.findStmt(ctx, db, columns, m.Table())
+	if err != nil {
+		return nil, err
+	}
+	m.query, m.bind = find.query, pk
+	row := new(R)
+	if err = find.stmt.GetContext(ctx, row, pk...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return row, nil
+}
+
+// preparedFind returns the connection and the parts of the queries, if Find
+// uses a prepared statement for this instance.
+func (m *Rx[R]) preparedFind() (*sqlx.DB, *queryParts, bool) {
+	db, isDB := m.tX().(*sqlx.DB)
+	_, own := Rowx(m.metaRow()).(interface{ Templates() Map })
+	_, restricted := rowPolicies.Load(m.Table())
+	parts := m.parts()
+	return db, parts, isDB && !own && !restricted && parts.cached
+}
+
+// pkColumns returns the columns, tagged as `pk` or `id`, if there are none.
+func (m *Rx[R]) pkColumns() []string {
+	names := fieldsMap[R]().Names
+	columns := make([]string, 0, 1)
+	for _, c := range m.Columns() {
+		if fi, ok := names[c]; ok && hasOption(fi, `pk`) {
+			columns = append(columns, c)
+		}
+	}
+	if len(columns) == 0 && slices.Contains(m.Columns(), `id`) {
+		columns = append(columns, `id`)
+	}
+	return columns
+}
+
+/*
+Explain returns the query plan of the database for the exact SQL query, which
+[Rx] would execute for the operation `op` with the given `where` clause and
+`bindData`. `op` is one of `SELECT`, `GET`, `COUNT` or `DELETE`. On SQLite
+`EXPLAIN QUERY PLAN` is used. The plan is returned line by line - the last
+column of each row of the result.
+*/
+func (m *Rx[R]) Explain(op, where string, bindData any) (_ []string, err error) {
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`EXPLAIN`, time.Now(), &err)
+	if err := checkWhere(where); err != nil {
+		return nil, err
+	}
+	var query string
+	switch op {
+	case `SELECT`:
+		query, err = m.renderSelectTemplate(where, []int{m.defaultLimit(), 0})
+	case `GET`:
+		query, err = m.renderSelectTemplate(where, []int{1, 0})
+	case `COUNT`, `DELETE`:
+		policyOp := `SELECT`
+		if op == `DELETE` {
+			policyOp = op
+		}
+		query, err = m.render(op, Map{`table`: m.Table(), `WHERE`: m.where(policyOp, where)})
+	default:
+		return nil, fmt.Errorf(`cannot explain operation '%s'`, op)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bindData == nil {
+		bindData = struct{}{}
+	}
+	q, args, err := m.namedInRebind(query, bindData)
+	if err != nil {
+		return nil, err
+	}
+	q, err = m.render(`EXPLAIN`, Map{`query`: q})
+	if err != nil {
+		return nil, err
+	}
+	rows, err := m.tX().QueryxContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanPlan(rows)
+}
+
+// scanPlan returns the last column of every row of the result of EXPLAIN and
+// closes `rows`.
+func scanPlan(rows *sqlx.Rows) ([]string, error) {
+	defer func() { _ = rows.Close() }()
+	plan := make([]string, 0, 4)
+	for rows.Next() {
+		cells, err := rows.SliceScan()
+		if err != nil {
+			return plan, err
+		}
+		plan = append(plan, sprintf(`%s`, cells[len(cells)-1]))
+	}
+	return plan, rows.Err()
+}
+
+/*
+explainTimedOut returns the query plan for `query`, which timed out, executing
+EXPLAIN on a separate connection, limited by [ExplainOnTimeout]. Errors are only
+logged, because the original error is more important.
+*/
+func (m *Rx[R]) explainTimedOut(query string, args []any) []string {
+	db, ok := m.queryer.(*sqlx.DB)
+	if !ok {
+		db = DB()
+	}
+	q, err := m.render(`EXPLAIN`, Map{`query`: query})
+	if err != nil {
+		m.logger().Warnf(`Could not explain the timed out query on %s: %s`, m.Table(), err)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ExplainOnTimeout)
+	defer cancel()
+	rows, err := db.QueryxContext(ctx, q, args...)
+	if err == nil {
+		var plan []string
+		if plan, err = scanPlan(rows); err == nil {
+			return plan
+		}
+	}
+	m.logger().Warnf(`Could not explain the timed out query on %s: %s`, m.Table(), err)
+	return nil
+}
+
+// explainIfSlow logs the query plan for a query, which took longer than
+// SlowQueryThreshold.
+func (m *Rx[R]) explainIfSlow(op, where string, bindData any, start time.Time) {
+	took := time.Since(start)
+	if SlowQueryThreshold <= 0 || took < SlowQueryThreshold {
+		return
+	}
+	plan, err := m.Explain(op, where, bindData)
+	if err != nil {
+		m.logger().Warnf(`Slow %s query on %s (%s); could not explain it: %s`, op, m.Table(), took, err)
+		return
+	}
+	m.logger().Warnf("Slow %s query on %s (%s). Query plan:\n%s", op, m.Table(), took, strings.Join(plan, "\n"))
+}
+
+var isWhere = regexp.MustCompile(`(?i:^\s*?where\s)`)
+
+func ifWhere(where string) string {
+	if where != `` && !isWhere.MatchString(where) {
+		where = sprintf(`WHERE %s`, where)
+	}
+	return where
+}
+
+/*
+checkWhere returns [ErrUnsafeWhere], if [StrictWhere] is true and `where`
+contains a statement terminator or a comment outside of quoted literals and
+identifiers.
+*/
+func checkWhere(where string) error {
+	if !StrictWhere {
+		return nil
+	}
+	var quote rune
+	runes := []rune(where)
+	for i, r := range runes {
+		if quote != 0 {
+			if r == quote {
+				quote = 0
+			}
+			continue
+		}
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+		switch {
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == ';',
+			r == '-' && next == '-',
+			r == '/' && next == '*',
+			r == '*' && next == '/':
+			return fmt.Errorf(`%w: '%c' at position %d in '%s'`, ErrUnsafeWhere, r, i, where)
+		}
+	}
+	return nil
+}
+
+/*
+namedInRebind prepares `query` with named bind parameters for execution on the
+connection of this instance. Slices in `bindData` are expanded for `IN(:ids)`,
+also together with scalar parameters and in subqueries. The parameters,
+redacted for R, are logged with their values replaced with [Redacted].
+*/
+func (m *Rx[R]) namedInRebind(query string, bindData any) (string, []any, error) {
+	bindData = m.withPolicyBind(bindData, namedMapper())
+	m.policyBind = nil
+	q, args, err := sqlx.Named(query, bindData)
+	if err != nil {
 		return query, args, err
 	}
 	q, args, err = sqlx.In(q, args...)
 	if err != nil {
 		return query, args, err
 	}
-	q = DB().Rebind(q)
-	Logger.Debugf(`Rebound query: %s|args:%+v| err: %+v`, q, args, err)
+	q = m.tX().Rebind(q)
+	m.query, m.bind, m.args = q, bindData, args
+	if redacted := m.redacted(); len(redacted) > 0 {
+		m.logger().Debugf(`Rebound query: %s|bind:%+v| err: %+v`, q, redact(bindData, redacted), err)
+	} else {
+		m.logger().Debugf(`Rebound query: %s|args:%+v| err: %+v`, q, args, err)
+	}
 	return q, args, err
 }
 
@@ -534,14 +2145,26 @@ columns types like [sql.NullInt32] and such, provided by the [sql] package.
 
 `fields` is the list of columns to be updated - used to construct the `SET col
 = :col...` part of the query. If a field starts with UppercaseLetter it is
-converted to snake_case.
+converted to snake_case. Fields, tagged as `rx:"field_name,insertonly"` (e.g.
+`created_at`) or `rx:"field_name,readonly"`, are never updated and are skipped
+with a warning.
 
 For any case in which this method is not suitable, use directly sqlx.
 */
-func (m *Rx[R]) Update(fields []string, where string) (sql.Result, error) {
+func (m *Rx[R]) Update(fields []string, where string) (_ sql.Result, err error) {
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`UPDATE`, time.Now(), &err)
+	if err := m.checkWrite(); err != nil {
+		return nil, err
+	}
 	if len(m.Data()) == 0 {
-		Logger.Panic("Cannot update, when no data is provided!")
+		m.logger().Panic("Cannot update, when no data is provided!")
 	}
+	if err := checkWhere(where); err != nil {
+		return nil, err
+	}
+	fields = m.updatable(fields)
 	var (
 		r sql.Result
 		e error
@@ -550,40 +2173,388 @@ func (m *Rx[R]) Update(fields []string, where string) (sql.Result, error) {
 	stash := map[string]any{
 		`table`: m.Table(),
 		// TODO: Prevent updating AutoFields in any case.
-		`SET`:   SQLForSET(fields),
-		`WHERE`: ifWhere(where),
+		`SET`:   sqlForSET(m.logger(), fields),
+		`WHERE`: m.where(`UPDATE`, where),
 	}
-	query := RenderSQLTemplate(`UPDATE`, stash)
-	Logger.Debugf("Rendered UPDATE query : %s;", query)
-	namedStmt, e := m.tX().PrepareNamed(query)
+	stash[`assignments`] = strings.TrimPrefix(stash[`SET`].(string), `SET `)
+	query, err := m.render(`UPDATE`, stash)
+	if err != nil {
+		return nil, err
+	}
+	m.query, m.bind = query, m.Data()
+	m.logger().Debugf("Rendered UPDATE query : %s;", query)
+	namedStmt, e := m.tX().PrepareNamedContext(ctx, query)
 	if e != nil {
 		return nil, e
 	}
 	defer func() { _ = namedStmt.Close() }()
 	for _, row := range m.Data() {
-		Logger.Debugf("Update row: %+v;", row)
-		r, e = namedStmt.Exec(row)
+		m.logger().Debugf("Update row: %+v;", m.loggable(row))
+		r, e = namedStmt.ExecContext(ctx, m.withPolicyBind(row, namedStmt.Stmt.Mapper))
 		if e != nil {
-			return r, e
+			return r, dbError(e)
 		}
 	}
 
 	return r, e
 }
 
+// updatable returns `fields` without the ones, tagged as `insertonly`,
+// `readonly` or `expr`.
+func (m *Rx[R]) updatable(fields []string) []string {
+	names := fieldsMap[R]().Names
+	return slices.DeleteFunc(slices.Clone(fields), func(field string) bool {
+		fi, ok := names[toColumn(field)]
+		if ok && hasOption(fi, `insertonly`, `readonly`, `expr`) {
+			m.logger().Warnf(`Skipping field %s in UPDATE; Options %v`, field, fi.Options)
+			return true
+		}
+		return false
+	})
+}
+
+/*
+selectColumns returns the comma separated list of columns for SELECT queries.
+Fields with tag option `expr=...` are selected as `(expression) AS column`.
+*/
+func (m *Rx[R]) selectColumns() string {
+	names := fieldsMap[R]().Names
+	columns := slices.Clone(m.Columns())
+	for i, col := range columns {
+		if fi, ok := names[col]; ok {
+			if expr, ok := exprOf(fi); ok {
+				columns[i] = sprintf(`(%s) AS %s`, expr, col)
+			}
+		}
+	}
+	return strings.Join(columns, `,`)
+}
+
+// outputColumns returns the columns for the OUTPUT clause of SQL Server,
+// qualified by `prefix` - INSERTED or DELETED. Computed columns (see
+// [Rx.Select]) are not in the table, so they are left out.
+func (m *Rx[R]) outputColumns(prefix string) string {
+	names := fieldsMap[R]().Names
+	columns := make([]string, 0, len(m.Columns()))
+	for _, col := range m.Columns() {
+		if fi, ok := names[col]; ok && hasOption(fi, `expr`) {
+			continue
+		}
+		columns = append(columns, prefix+`.`+col)
+	}
+	return strings.Join(columns, `,`)
+}
+
+// exprOf returns the expression from the tag option `expr=...` of the field.
+// It is the rest of the tag, because [reflectx] cuts option values at `,`
+// and `=`.
+func exprOf(fi *reflectx.FieldInfo) (string, bool) {
+	if !hasOption(fi, `expr`) {
+		return ``, false
+	}
+	_, expr, ok := strings.Cut(fi.Field.Tag.Get(ReflectXTag), `,expr=`)
+	return expr, ok
+}
+
+// hasOption reports if the field has any of the given tag options.
+func hasOption(fi *reflectx.FieldInfo, options ...string) bool {
+	for _, o := range options {
+		if _, ok := fi.Options[o]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+UpdateBulk updates many rows with different values in one statement, instead
+of executing a prepared statement per row like [Rx.Update]. The rows are
+matched by `keyColumn` (usually `id`). For every column in `fields` a `CASE
+keyColumn WHEN ? THEN ? ... ELSE column END` expression is rendered, so the
+query works on all supported databases. The rows are restricted by the
+[RowPolicy] for UPDATE of the table, if any. It panics if there is no data to
+be updated.
+*/
+func (m *Rx[R]) UpdateBulk(fields []string, keyColumn string) (_ sql.Result, err error) {
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`UPDATE_BULK`, time.Now(), &err)
+	if err := m.checkWrite(); err != nil {
+		return nil, err
+	}
+	if len(m.Data()) == 0 {
+		m.logger().Panic("Cannot update, when no data is provided!")
+	}
+	fields = m.updatable(fields)
+	keys := make([]any, len(m.data))
+	args := make([]any, 0, (len(fields)*2+1)*len(m.data))
+	for i := range m.data {
+		var err error
+		if keys[i], err = fieldValue(&m.data[i], keyColumn); err != nil {
+			return nil, err
+		}
+	}
+	set := make([]string, 0, len(fields))
+	for _, field := range fields {
+		column := toColumn(field)
+		var expr strings.Builder
+		expr.WriteString(sprintf(`%s = CASE %s`, column, keyColumn))
+		for i := range m.data {
+			v, err := fieldValue(&m.data[i], column)
+			if err != nil {
+				return nil, err
+			}
+			expr.WriteString(` WHEN ? THEN ?`)
+			args = append(args, keys[i], v)
+		}
+		expr.WriteString(sprintf(` ELSE %s END`, column))
+		set = append(set, expr.String())
+	}
+	args = append(args, keys...)
+	andPolicy, policyArgs, err := m.andPolicy(`UPDATE`)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, policyArgs...)
+	if templates, k := m.templateKey(`UPDATE_BULK`); andPolicy != `` &&
+		!strings.Contains(templates[k].(string), `${and_policy}`) {
+		return nil, fmt.Errorf(`%w: template %s has no slot ${and_policy}`, ErrRowPolicy, k)
+	}
+	query, err := m.render(`UPDATE_BULK`, Map{
+		`table`:       m.Table(),
+		`SET`:         `SET ` + strings.Join(set, `, `),
+		`assignments`: strings.Join(set, `, `),
+		`key`:         keyColumn,
+		`keys`:        strings.TrimSuffix(strings.Repeat(`?,`, len(keys)), `,`),
+		`and_policy`:  andPolicy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.query, m.bind = query, m.Data()
+	m.logger().Debugf("Rendered UPDATE_BULK query : %s;", query)
+	r, err := m.tX().ExecContext(ctx, m.tX().Rebind(query), args...)
+	return r, dbError(err)
+}
+
+// fieldValue returns the value of the field of `row`, mapped to `column`.
+func fieldValue[R Rowx](row *R, column string) (any, error) {
+	field := fieldsMap[R]().GetByPath(column)
+	if field == nil {
+		return nil, fmt.Errorf(`column %s not found in %T`, column, row)
+	}
+	return reflectx.FieldByIndexesReadOnly(reflect.ValueOf(row).Elem(), field.Index).Interface(), nil
+}
+
 /*
-Delete deletes records from the database.
+UpdateReturning executes the same UPDATE query as [Rx.Update] for each row of
+data, but with a RETURNING clause, and returns the updated rows as they are in
+the database after the update. So a follow-up Select is not needed. It returns
+[ErrNoReturning] for databases, which do not support RETURNING (see
+`UPDATE_RETURNING` in [QueryTemplates]). On SQL Server the rows are returned by
+an OUTPUT clause. It panics if there is no data to be
+updated.
 */
-func (m *Rx[R]) Delete(where string, bindData any) (sql.Result, error) {
+func (m *Rx[R]) UpdateReturning(fields []string, where string) (_ []R, err error) {
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`UPDATE_RETURNING`, time.Now(), &err)
+	if err := m.checkWrite(); err != nil {
+		return nil, err
+	}
+	if len(m.Data()) == 0 {
+		m.logger().Panic("Cannot update, when no data is provided!")
+	}
+	if err := checkWhere(where); err != nil {
+		return nil, err
+	}
+	fields = m.updatable(fields)
+	query, err := m.render(`UPDATE_RETURNING`, Map{
+		`table`:            m.Table(),
+		`SET`:              sqlForSET(m.logger(), fields),
+		`WHERE`:            m.where(`UPDATE`, where),
+		`columns`:          m.parts().selectColumns,
+		`inserted_columns`: m.outputColumns(`INSERTED`),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if query == `` {
+		return nil, ErrNoReturning
+	}
+	m.query, m.bind = query, m.Data()
+	m.logger().Debugf("Rendered UPDATE RETURNING query : %s;", query)
+	namedStmt, err := m.tX().PrepareNamedContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = namedStmt.Close() }()
+	updated := make([]R, 0, len(m.Data()))
+	for _, row := range m.Data() {
+		rows := []R{}
+		if err = namedStmt.SelectContext(ctx, &rows, m.withPolicyBind(row, namedStmt.Stmt.Mapper)); err != nil {
+			return updated, dbError(err)
+		}
+		updated = append(updated, rows...)
+	}
+	return updated, nil
+}
+
+/*
+DeleteReturning deletes records like [Rx.Delete], but returns the deleted rows.
+It returns [ErrNoReturning] for databases, which do not support RETURNING (see
+`DELETE_RETURNING` in [QueryTemplates]). On SQL Server the rows are returned by
+an OUTPUT clause.
+*/
+func (m *Rx[R]) DeleteReturning(where string, bindData any) (_ []R, err error) {
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`DELETE_RETURNING`, time.Now(), &err)
+	if err := m.checkWrite(); err != nil {
+		return nil, err
+	}
+	if err := checkWhere(where); err != nil {
+		return nil, err
+	}
+	if bindData == nil {
+		bindData = map[string]any{}
+	}
+	query, err := m.render(`DELETE_RETURNING`, Map{
+		`table`:           m.Table(),
+		`WHERE`:           m.where(`DELETE`, where),
+		`columns`:         m.parts().selectColumns,
+		`deleted_columns`: m.outputColumns(`DELETED`),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if query == `` {
+		return nil, ErrNoReturning
+	}
+	m.logger().Debugf("Rendered DELETE RETURNING query : %s", query)
+	q, args, err := m.namedInRebind(query, bindData)
+	if err != nil {
+		return nil, err
+	}
+	deleted := []R{}
+	err = sqlx.SelectContext(ctx, m.tX(), &deleted, q, args...)
+	return deleted, dbError(err)
+}
+
+/*
+Delete deletes records from the database. Like in [Rx.Select], slices in
+`bindData` are expanded: `id IN(:ids)`.
+*/
+func (m *Rx[R]) Delete(where string, bindData any) (_ sql.Result, err error) {
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`DELETE`, time.Now(), &err)
+	if err := m.checkWrite(); err != nil {
+		return nil, err
+	}
+	if err := checkWhere(where); err != nil {
+		return nil, err
+	}
 	stash := map[string]any{
 		`table`: m.Table(),
-		`WHERE`: ifWhere(where),
+		`WHERE`: m.where(`DELETE`, where),
 	}
 	if bindData == nil {
 		bindData = map[string]any{}
 	}
-	query := RenderSQLTemplate(`DELETE`, stash)
-	Logger.Debugf("Constructed DELETE query : %s", query)
+	query, err := m.render(`DELETE`, stash)
+	if err != nil {
+		return nil, err
+	}
+	m.logger().Debugf("Constructed DELETE query : %s", query)
+	q, args, err := m.namedInRebind(query, bindData)
+	if err != nil {
+		return nil, err
+	}
+	r, err := m.tX().ExecContext(ctx, q, args...)
+	return r, dbError(err)
+}
 
-	return sqlx.NamedExec(m.tX(), query, bindData)
+/*
+Truncate deletes all records from the table and resets its autoincrement
+sequence. On SQLite it executes `DELETE FROM table` and resets the sequence in
+`sqlite_sequence` (see [ResetAutoIncrement]) in the same transaction. Elsewhere
+it executes `TRUNCATE TABLE table`. Tables with a [RowPolicy] can not be truncated - it returns
+[ErrRowPolicy] for them.
+*/
+func (m *Rx[R]) Truncate() (_ sql.Result, err error) {
+	ctx, cancel := m.opCtx()
+	defer cancel()
+	defer m.track(`TRUNCATE`, time.Now(), &err)
+	if err := m.checkWrite(); err != nil {
+		return nil, err
+	}
+	if _, ok := rowPolicies.Load(m.Table()); ok {
+		return nil, fmt.Errorf(`%w: truncate %s`, ErrRowPolicy, m.Table())
+	}
+	query, err := m.render(`TRUNCATE`, Map{`table`: m.Table()})
+	if err != nil {
+		return nil, err
+	}
+	m.query = query
+	m.logger().Debugf("Rendered TRUNCATE query : %s", query)
+	ex := m.tX()
+	db, outsideTx := ex.(*sqlx.DB)
+	var tx *sqlx.Tx
+	if outsideTx {
+		if tx, err = db.BeginTxx(ctx, nil); err != nil {
+			return nil, err
+		}
+		// The rollback will be ignored if the tx has been committed already.
+		defer func() { _ = tx.Rollback() }()
+		ex = tx
+	}
+	r, err := ex.ExecContext(ctx, query)
+	if err != nil {
+		return r, err
+	}
+	if err = resetAutoIncrement(ctx, m.logger(), ex, m.Table()); err != nil {
+		return nil, err
+	}
+	if outsideTx {
+		err = tx.Commit()
+	}
+	return r, err
+}
+
+/*
+ResetAutoIncrement resets the autoincrement sequence for `table`, so the
+next inserted primary key starts from 1. On SQLite nothing is reset, if no
+table was created with AUTOINCREMENT, so `sqlite_sequence` does not exist.
+*/
+func ResetAutoIncrement(table string) error {
+	if ReadOnly {
+		return fmt.Errorf(`%w: %s`, ErrReadOnly, table)
+	}
+	return resetAutoIncrement(context.Background(), Logger, DB(), table)
+}
+
+func resetAutoIncrement(ctx context.Context, l *log.Logger, ex Ext, table string) error {
+	has, err := queryTemplate(dialectKey(`HAS_AUTOINCREMENT`, ex.DriverName()))
+	if err != nil {
+		return err
+	}
+	// An empty HAS_AUTOINCREMENT means that the sequence is always there.
+	if has != `` {
+		var found int
+		if err = sqlx.GetContext(ctx, ex, &found, has); err != nil {
+			return err
+		}
+		if found == 0 {
+			l.Debugf("No autoincrement sequence to reset for %s", table)
+			return nil
+		}
+	}
+	query, err := RenderSQLTemplateE(dialectKey(`RESET_AUTOINCREMENT`, ex.DriverName()), Map{`table`: table})
+	if err != nil {
+		return err
+	}
+	l.Debugf("Rendered RESET_AUTOINCREMENT query : %s", query)
+	_, err = sqlx.NamedExecContext(ctx, ex, query, Map{`table`: table})
+	return err
 }
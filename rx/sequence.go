@@ -0,0 +1,77 @@
+package rx
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+)
+
+/*
+NextSequence returns the next value of the sequence `name` in the database,
+connected via [DB], so an ID can be reserved before the row is inserted. It is
+rendered from the `NEXT_SEQUENCE` templates in [QueryTemplates]:
+
+  - PostgreSQL and DuckDB - `nextval`. The sequence of a serial or identity
+    column `id` is named `<table>_id_seq` by default;
+  - SQLite - `name` is a table with an AUTOINCREMENT primary key. Its counter
+    in `sqlite_sequence` is incremented, so the value is never assigned to
+    another row. A row must have been inserted in the table before;
+  - others - the standard `NEXT VALUE FOR name` (SQL Server, MariaDB).
+
+MySQL has no sequences. Use [SetAutoIncrement] there.
+
+	id, err := rx.NextSequence(`users_id_seq`)
+*/
+func NextSequence(name string) (int64, error) {
+	if ReadOnly {
+		return 0, fmt.Errorf(`%w: %s`, ErrReadOnly, name)
+	}
+	return nextSequence(context.Background(), DB(), name)
+}
+
+func nextSequence(ctx context.Context, db *sqlx.DB, name string) (next int64, err error) {
+	query, err := queryTemplate(dialectKey(`NEXT_SEQUENCE`, db.DriverName()))
+	if err != nil {
+		return 0, err
+	}
+	if query == `` {
+		return 0, fmt.Errorf(`sequences are not supported by %s`, db.DriverName())
+	}
+	bind := Map{`name`: name}
+	if query, err = replaceE(query, bind); err != nil {
+		return 0, err
+	}
+	q, args, err := sqlx.Named(query, bind)
+	if err != nil {
+		return 0, err
+	}
+	err = db.GetContext(ctx, &next, db.Rebind(q), args...)
+	return next, dbError(err)
+}
+
+/*
+SetAutoIncrement makes `next` the value, which the database assigns to the
+primary key `id` of the next row, inserted in `table`. Use it to reserve a range
+of IDs or to continue after imported rows. It is rendered from the
+`SET_AUTOINCREMENT` templates in [QueryTemplates]: `AUTO_INCREMENT` in MySQL,
+`setval` of the sequence of the serial or identity column in PostgreSQL and
+`sqlite_sequence` in SQLite, where the table must have an AUTOINCREMENT
+primary key and a row must have been inserted in it before. See also
+[ResetAutoIncrement].
+*/
+func SetAutoIncrement(table string, next int64) error {
+	if ReadOnly {
+		return fmt.Errorf(`%w: %s`, ErrReadOnly, table)
+	}
+	db := DB()
+	query, err := RenderSQLTemplateE(dialectKey(`SET_AUTOINCREMENT`, db.DriverName()),
+		Map{`table`: table, `next`: strconv.FormatInt(next, 10)})
+	if err != nil {
+		return err
+	}
+	Logger.Debugf("Rendered SET_AUTOINCREMENT query : %s", query)
+	_, err = db.NamedExec(query, Map{`table`: table, `next`: next})
+	return err
+}
@@ -0,0 +1,318 @@
+package rx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/labstack/gommon/log"
+)
+
+/*
+Session is a unit of work. It groups the state, shared by several models -
+a context, a connection, an optional transaction, a logger and information
+about the current tenant, instead of setting it on every model or relying on
+the package-level [DB] and [Logger]. Typed models are obtained from it by
+[Model]. A Session is not safe for concurrent use.
+
+	s := rx.NewSession(ctx, nil).WithTenant(tenantID)
+	if err := s.Begin(); err != nil {
+		return err
+	}
+	defer func() { _ = s.Rollback() }()
+	s.OnCommit(func(s *rx.Session) { notify(s.Tenant()) })
+	if _, err := rx.Model(s, users...).Insert(); err != nil {
+		return err
+	}
+	if _, err := rx.Model[Groups](s).Delete(`id=:id`, rx.Map{`id`: 3}); err != nil {
+		return err
+	}
+	return s.Commit()
+*/
+type Session struct {
+	ctx        context.Context
+	db         *sqlx.DB
+	tx         *sqlx.Tx
+	log        *log.Logger
+	tenant     any
+	onCommit   []func(*Session)
+	onRollback []func(*Session)
+}
+
+// ErrNoTx is returned by [Session.Commit] and [Session.Rollback], when no
+// transaction was started by [Session.Begin].
+var ErrNoTx = errors.New(`no transaction in this session`)
+
+/*
+NewSession returns a new unit of work, which executes the queries of its models
+on `db` with `ctx`. If `db` is nil, [DB] is used. If `ctx` is nil,
+[context.Background] is used.
+*/
+func NewSession(ctx context.Context, db *sqlx.DB) *Session {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if db == nil {
+		db = DB()
+	}
+	return &Session{ctx: ctx, db: db}
+}
+
+// Context returns the context of the session.
+func (s *Session) Context() context.Context {
+	return s.ctx
+}
+
+// Tx returns the current transaction of the session or nil.
+func (s *Session) Tx() *sqlx.Tx {
+	return s.tx
+}
+
+// WithTenant sets information about the tenant, on behalf of which the work is
+// done. It is available to the hooks and the application via [Session.Tenant].
+func (s *Session) WithTenant(tenant any) *Session {
+	s.tenant = tenant
+	return s
+}
+
+// Tenant returns the information, set by [Session.WithTenant].
+func (s *Session) Tenant() any {
+	return s.tenant
+}
+
+// WithLogger sets the logger for all models of the session. See
+// [Rx.WithLogger].
+func (s *Session) WithLogger(l *log.Logger) *Session {
+	s.log = l
+	return s
+}
+
+// OnCommit adds a hook, called after the transaction of the session is
+// committed successfully.
+func (s *Session) OnCommit(hook func(*Session)) {
+	s.onCommit = append(s.onCommit, hook)
+}
+
+// OnRollback adds a hook, called after the transaction of the session is
+// rolled back.
+func (s *Session) OnRollback(hook func(*Session)) {
+	s.onRollback = append(s.onRollback, hook)
+}
+
+/*
+Begin starts a transaction, in which the queries of all models, obtained
+afterwards from the session, are executed.
+*/
+func (s *Session) Begin() error {
+	return s.BeginTx(nil)
+}
+
+/*
+BeginTx is like [Session.Begin], but starts the transaction with `opts` - an
+isolation level and if it is read-only. The database may not support all
+options. SQLite ignores them.
+*/
+func (s *Session) BeginTx(opts *sql.TxOptions) (err error) {
+	if s.tx != nil {
+		return errors.New(`a transaction is already started in this session`)
+	}
+	s.tx, err = s.db.BeginTxx(s.ctx, opts)
+	return err
+}
+
+// Commit commits the transaction of the session and calls the hooks, added by
+// [Session.OnCommit].
+func (s *Session) Commit() error {
+	return s.end((*sqlx.Tx).Commit, s.onCommit)
+}
+
+/*
+Rollback rolls back the transaction of the session and calls the hooks, added
+by [Session.OnRollback]. It returns [ErrNoTx] if there is no transaction, so it
+is safe to defer it after [Session.Begin] and ignore the error.
+*/
+func (s *Session) Rollback() error {
+	return s.end((*sqlx.Tx).Rollback, s.onRollback)
+}
+
+func (s *Session) end(finish func(*sqlx.Tx) error, hooks []func(*Session)) error {
+	if s.tx == nil {
+		return ErrNoTx
+	}
+	tx := s.tx
+	s.tx = nil
+	if err := finish(tx); err != nil {
+		return dbError(err)
+	}
+	for _, hook := range hooks {
+		hook(s)
+	}
+	return nil
+}
+
+/*
+Savepoint marks the current state of the transaction of the session with
+`name`, so the work, done after it, can be undone by [Session.RollbackTo]
+without aborting the whole transaction. `name` must be an identifier.
+
+	if err := s.Savepoint(`enrich`); err != nil {
+		return err
+	}
+	if _, err := rx.Model(s, extras...).Insert(); err != nil {
+		// The enrichment is optional.
+		_ = s.RollbackTo(`enrich`)
+	}
+	return s.Release(`enrich`)
+*/
+func (s *Session) Savepoint(name string) error {
+	return s.savepoint(`SAVEPOINT`, name)
+}
+
+// RollbackTo undoes the work, done in the transaction of the session after
+// [Session.Savepoint] with `name`. The savepoint remains.
+func (s *Session) RollbackTo(name string) error {
+	return s.savepoint(`ROLLBACK_TO_SAVEPOINT`, name)
+}
+
+// Release forgets the savepoint `name` and the ones after it. The work, done
+// after it, remains part of the transaction.
+func (s *Session) Release(name string) error {
+	return s.savepoint(`RELEASE_SAVEPOINT`, name)
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+
+func (s *Session) savepoint(key, name string) error {
+	if s.tx == nil {
+		return ErrNoTx
+	}
+	if !identifier.MatchString(name) {
+		return fmt.Errorf(`savepoint name must be an identifier, but it is '%s'`, name)
+	}
+	query, err := RenderSQLTemplateE(dialectKey(key, s.db.DriverName()), Map{`name`: name})
+	if err != nil || query == `` {
+		return err
+	}
+	_, err = s.tx.ExecContext(s.ctx, query)
+	return dbError(err)
+}
+
+/*
+Model returns a new model for the rows of type R, which executes its queries
+in the context and transaction (or on the connection) of `s` and logs with its
+logger.
+*/
+func Model[R Rowx](s *Session, rows ...R) SqlxModel[R] {
+	m := &Rx[R]{data: rows, r: nilRowx[R](), queryer: s.db, log: s.log, context: s.ctx}
+	if s.tx != nil {
+		m.queryer = s.tx
+	}
+	return m
+}
+
+/*
+TransactRetries is the maximal number of times [Transact] retries a
+transaction on CockroachDB, after it failed with [ErrSerializationFailure].
+*/
+var TransactRetries = 10
+
+// cockroaches caches for every connection pool if it is to CockroachDB.
+var cockroaches sync.Map
+
+/*
+Transact executes `fn` in a transaction of a new [Session] on `db` (or [DB] if
+nil). The transaction is committed if `fn` returns nil and rolled back
+otherwise. The error of `fn` is returned as is. If `fn` panics, the transaction
+is rolled back and the panic goes on.
+
+On CockroachDB (driver `cockroach` or a PostgreSQL driver, connected to
+CockroachDB) it implements the recommended client-side retry loop: `fn` is
+executed after `SAVEPOINT cockroach_restart` and, if it or the release of the
+savepoint fails with [ErrSerializationFailure], the transaction is rolled back
+to the savepoint and `fn` is executed again up to [TransactRetries] times. So
+`fn` must be safe to execute more than once. The hooks, added by `fn` with
+[Session.OnCommit] and [Session.OnRollback], are dropped before every retry.
+
+	err := rx.Transact(ctx, nil, func(s *rx.Session) error {
+		_, err := rx.Model(s, users...).Insert()
+		return err
+	})
+*/
+func Transact(ctx context.Context, db *sqlx.DB, fn func(*Session) error) error {
+	return TransactWith(ctx, db, sql.TxOptions{}, fn)
+}
+
+/*
+TransactWith is like [Transact], but starts the transaction with `opts`.
+
+	err := rx.TransactWith(ctx, nil, sql.TxOptions{Isolation: sql.LevelSerializable},
+		func(s *rx.Session) error {
+			return transfer(s, from, to, amount)
+		})
+*/
+func TransactWith(ctx context.Context, db *sqlx.DB, opts sql.TxOptions, fn func(*Session) error) (err error) {
+	s := NewSession(ctx, db)
+	if err = s.BeginTx(&opts); err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = s.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			_ = s.Rollback()
+		}
+	}()
+	if !isCockroach(s.ctx, s.db) {
+		if err = fn(s); err != nil {
+			return err
+		}
+		return s.Commit()
+	}
+	if err = s.Savepoint(`cockroach_restart`); err != nil {
+		return err
+	}
+	onCommit, onRollback := len(s.onCommit), len(s.onRollback)
+	for retry := 0; ; retry++ {
+		if err = fn(s); err == nil {
+			if err = s.Release(`cockroach_restart`); err == nil {
+				return s.Commit()
+			}
+		}
+		if !errors.Is(err, ErrSerializationFailure) || retry >= TransactRetries {
+			return err
+		}
+		Logger.Debugf(`Retrying transaction after: %s`, err)
+		s.onCommit, s.onRollback = s.onCommit[:onCommit], s.onRollback[:onRollback]
+		if err = s.RollbackTo(`cockroach_restart`); err != nil {
+			return err
+		}
+	}
+}
+
+// isCockroach reports if `db` is connected to CockroachDB.
+func isCockroach(ctx context.Context, db *sqlx.DB) bool {
+	switch db.DriverName() {
+	case `cockroach`:
+		return true
+	case `postgres`, `pgx`:
+	default:
+		return false
+	}
+	if is, ok := cockroaches.Load(db); ok {
+		return is.(bool)
+	}
+	var version string
+	if err := db.GetContext(ctx, &version, `SELECT version()`); err != nil {
+		return false
+	}
+	is := strings.Contains(version, `CockroachDB`)
+	cockroaches.Store(db, is)
+	return is
+}
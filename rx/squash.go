@@ -0,0 +1,74 @@
+package rx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/*
+Squash writes the current schema of the database, pointed to by `dsn`, to the
+file `outPath` as one baseline migration, labeled `baseline`, with the current
+time as version. The baseline is recorded as applied in [MigrationsTable] and
+all previous migrations are marked as superseded by it, so the old migration
+files can be removed. New databases are created from the baseline by
+[Migrate]. The data is not dumped. Returns the version of the baseline. The
+statements are selected with `SELECT_SCHEMA` from [QueryTemplates]. Only
+SQLite is supported for now.
+
+	version, err := rx.Squash(dsn, `migrations/baseline.sql`)
+*/
+func Squash(dsn, outPath string) (version string, err error) {
+	abs, err := filepath.Abs(outPath)
+	if err != nil {
+		return ``, err
+	}
+	if !allowedPath(abs) {
+		return ``, fmt.Errorf(`%s is outside of %v: %w`, abs, AllowedRoots, ErrUnsafePath)
+	}
+	db, disconnect, err := connectTo(dsn)
+	if err != nil {
+		return ``, err
+	}
+	defer disconnect()
+	query, err := queryTemplate(dialectKey(`SELECT_SCHEMA`, db.DriverName()))
+	if err != nil {
+		return ``, err
+	}
+	if query == `` {
+		return ``, fmt.Errorf(`squashing migrations is not supported for %s`, db.DriverName())
+	}
+	if err = ensureMigrationsTable(db); err != nil {
+		return ``, err
+	}
+	var statements []string
+	if err = db.Select(&statements, db.Rebind(query), MigrationsTable); err != nil {
+		return ``, err
+	}
+	version = time.Now().UTC().Format(`20060102150405`)
+	var baseline strings.Builder
+	baseline.WriteString(sprintf("-- %s baseline up\n", version))
+	for _, s := range statements {
+		baseline.WriteString(s + ";\n\n")
+	}
+	if err = os.WriteFile(abs, []byte(baseline.String()), 0600); err != nil {
+		return ``, fmt.Errorf("os.WriteFile: %w", err)
+	}
+	tx, err := db.Beginx()
+	if err != nil {
+		return ``, err
+	}
+	// The rollback will be ignored if the tx has been committed already.
+	defer func() { _ = tx.Rollback() }()
+	if _, err = tx.Exec(tx.Rebind(sprintf(`UPDATE %s SET superseded_by = ? WHERE superseded_by = ''`,
+		MigrationsTable)), version); err != nil {
+		return ``, err
+	}
+	if _, err = newRxOn(tx, Migrations{
+		Version: version, Direction: up.String(), FilePath: outPath, Label: `baseline`}).Insert(); err != nil {
+		return ``, err
+	}
+	return version, tx.Commit()
+}
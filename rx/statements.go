@@ -0,0 +1,154 @@
+package rx
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// createTrigger matches the beginning of CREATE TRIGGER statements, which
+	// bodies contain semicolons between BEGIN and END.
+	createTrigger = regexp.MustCompile(`(?is)^\s*CREATE\s+(?:TEMP(?:ORARY)?\s+)?TRIGGER\b`)
+	// dollarTag matches the opening tag of a PostgreSQL dollar-quoted string.
+	dollarTag = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+)
+
+/*
+SplitStatements splits `sql` into statements, separated by semicolons. String
+literals, quoted identifiers, PostgreSQL dollar-quoted strings, comments and
+the bodies of triggers (between BEGIN and END) are respected. The terminating
+semicolons are removed and statements, which contain only comments, are
+skipped. [Migrate] executes the statements of every migration one by one,
+because some drivers execute only the first statement, if they get more at
+once.
+*/
+func SplitStatements(sql string) []string {
+	var statements []string
+	start, depth := 0, 0
+	// code is true after the first character of the current statement, which
+	// is not a space or a comment.
+	code, trigger := false, false
+	add := func(end int) {
+		if code {
+			statements = append(statements, strings.TrimSpace(sql[start:end]))
+		}
+		start, depth, code, trigger = end+1, 0, false, false
+	}
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '-' && strings.HasPrefix(sql[i:], `--`):
+			i = skipTo(sql, i, "\n")
+			continue
+		case c == '/' && strings.HasPrefix(sql[i:], `/*`):
+			i = skipTo(sql, i+2, `*/`)
+			continue
+		case unicode.IsSpace(rune(c)):
+			continue
+		}
+		if !code && c != ';' {
+			code, trigger = true, createTrigger.MatchString(sql[i:])
+		}
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipTo(sql, i+1, string(c))
+		case c == '[':
+			i = skipTo(sql, i+1, `]`)
+		case c == '$':
+			if tag := dollarTag.FindString(sql[i:]); tag != `` {
+				i = skipTo(sql, i+len(tag), tag)
+			}
+		case c == ';' && depth == 0:
+			add(i)
+		case trigger && isWordByte(c) && (i == 0 || !isWordByte(sql[i-1])):
+			end := i
+			for end < len(sql) && isWordByte(sql[end]) {
+				end++
+			}
+			switch strings.ToUpper(sql[i:end]) {
+			case `BEGIN`, `CASE`:
+				depth++
+			case `END`:
+				depth--
+			}
+			i = end - 1
+		}
+	}
+	add(len(sql))
+	return statements
+}
+
+// skipTo returns the position of the last byte of the first `end` in `sql`
+// after `from` or the end of `sql`.
+func skipTo(sql string, from int, end string) int {
+	if from > len(sql) {
+		return len(sql)
+	}
+	if i := strings.Index(sql[from:], end); i >= 0 {
+		return from + i + len(end) - 1
+	}
+	return len(sql)
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+/*
+keywordIndex returns the index in `sql` of the first of `keywords` (e.g.
+`ORDER BY`), which is not in a quoted literal or identifier, a comment or
+parentheses (a subquery or a function call), or -1. Keywords are matched in
+any case, as whole words and with any white space between their words.
+*/
+func keywordIndex(sql string, keywords ...string) int {
+	depth := 0
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '-' && strings.HasPrefix(sql[i:], `--`):
+			i = skipTo(sql, i, "\n")
+		case c == '/' && strings.HasPrefix(sql[i:], `/*`):
+			i = skipTo(sql, i+2, `*/`)
+		case c == '\'' || c == '"' || c == '`':
+			i = skipTo(sql, i+1, string(c))
+		case c == '[':
+			i = skipTo(sql, i+1, `]`)
+		case c == '$':
+			if tag := dollarTag.FindString(sql[i:]); tag != `` {
+				i = skipTo(sql, i+len(tag), tag)
+			}
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && (i == 0 || !isWordByte(sql[i-1])):
+			for _, k := range keywords {
+				if keywordAt(sql, i, k) {
+					return i
+				}
+			}
+		}
+	}
+	return -1
+}
+
+// keywordAt reports if the words of `keyword` are at `i` in `sql`, separated
+// by white space and followed by a non-word character or the end of `sql`.
+func keywordAt(sql string, i int, keyword string) bool {
+	for n, word := range strings.Fields(keyword) {
+		if n > 0 {
+			start := i
+			for i < len(sql) && unicode.IsSpace(rune(sql[i])) {
+				i++
+			}
+			if i == start {
+				return false
+			}
+		}
+		if len(sql)-i < len(word) || !strings.EqualFold(sql[i:i+len(word)], word) {
+			return false
+		}
+		i += len(word)
+	}
+	return i == len(sql) || !isWordByte(sql[i])
+}
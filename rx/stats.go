@@ -0,0 +1,120 @@
+package rx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// QueryStat holds statistics about executed queries.
+type QueryStat struct {
+	// Count is the number of executed queries.
+	Count int64
+	// Errors is the number of queries, which returned an error.
+	Errors int64
+	// Total is the cumulative time, spent in the queries.
+	Total time.Duration
+}
+
+// Stats are the statistics, returned by [QueryStats].
+type Stats struct {
+	// ByTemplate is keyed by the name of the template in [QueryTemplates]
+	// (without the driver suffix), e.g. `SELECT`.
+	ByTemplate map[string]QueryStat
+	// ByTable is keyed by table name.
+	ByTable map[string]QueryStat
+}
+
+// queryCounter counts the operations for a template or a table. Its fields are
+// updated atomically, so the queries do not wait for each other.
+type queryCounter struct {
+	count, errors, total atomic.Int64
+}
+
+func (c *queryCounter) stat() QueryStat {
+	return QueryStat{Count: c.count.Load(), Errors: c.errors.Load(), Total: time.Duration(c.total.Load())}
+}
+
+// stats keeps a *queryCounter by template and by table.
+var stats struct {
+	byTemplate, byTable sync.Map
+}
+
+// counter returns the counter for `key` in `counters`, adding it if needed.
+func counter(counters *sync.Map, key string) *queryCounter {
+	if c, ok := counters.Load(key); ok {
+		return c.(*queryCounter)
+	}
+	c, _ := counters.LoadOrStore(key, new(queryCounter))
+	return c.(*queryCounter)
+}
+
+// snapshot returns the current values of `counters`.
+func snapshot(counters *sync.Map) map[string]QueryStat {
+	stat := map[string]QueryStat{}
+	counters.Range(func(k, c any) bool {
+		stat[k.(string)] = c.(*queryCounter).stat()
+		return true
+	})
+	return stat
+}
+
+/*
+QueryStats returns a snapshot of the statistics about the operations of all
+models since the start of the program or the last [ResetQueryStats]. It is
+meant for debugging endpoints and status output. An operation is counted once,
+even if it executes several statements (e.g. [Rx.Update] for many rows).
+*/
+func QueryStats() Stats {
+	return Stats{ByTemplate: snapshot(&stats.byTemplate), ByTable: snapshot(&stats.byTable)}
+}
+
+// ResetQueryStats clears the statistics, returned by [QueryStats].
+func ResetQueryStats() {
+	stats.byTemplate.Clear()
+	stats.byTable.Clear()
+}
+
+/*
+track adds an operation, rendered from the template `key`, which started at
+`start` and ended with the error, pointed to by `err`, to the statistics. The
+error is wrapped by [Rx.queryError]. It is meant to be deferred.
+*/
+func (m *Rx[R]) track(key string, start time.Time, err *error) {
+	took := time.Since(start)
+	table := m.Table()
+	*err = m.queryError(key, *err)
+	for _, c := range [...]*queryCounter{counter(&stats.byTemplate, key), counter(&stats.byTable, table)} {
+		c.count.Add(1)
+		c.total.Add(int64(took))
+		if *err != nil {
+			c.errors.Add(1)
+		}
+	}
+}
+
+/*
+queryError wraps `err` of the operation, rendered from the template `key`, in a
+[QueryError] with the last executed query, unless it is [sql.ErrNoRows] or
+[ErrNotFound], so they can still be compared with `==`. An error, which is
+already a [QueryError], is returned as is.
+*/
+func (m *Rx[R]) queryError(key string, err error) error {
+	query, bind, args := m.query, m.bind, m.args
+	m.query, m.bind, m.args = ``, nil, nil
+	qe := (*QueryError)(nil)
+	if err == nil || errors.Is(err, sql.ErrNoRows) || errors.As(err, &qe) {
+		return err
+	}
+	if bind != nil {
+		bind = redact(bind, redactedNames[R]())
+	}
+	qe = &QueryError{Op: key, Table: m.Table(), Query: query, Args: bind, Err: err}
+	if ExplainOnTimeout > 0 && args != nil && errors.Is(err, context.DeadlineExceeded) {
+		qe.Plan = m.explainTimedOut(query, args)
+	}
+	return qe
+}
@@ -0,0 +1,4 @@
+CREATE TABLE labels (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(50) UNIQUE NOT NULL
+);
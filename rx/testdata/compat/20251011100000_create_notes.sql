@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE notes (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  body TEXT NOT NULL DEFAULT ''
+);
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE notes;
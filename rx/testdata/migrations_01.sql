@@ -339,3 +339,17 @@ OAuth VARCHAR NOT NULL DEFAULT ''
 -- 202510022303 down
 DROP TABLE IF EXISTS other_types;
 DROP TABLE IF EXISTS oauth;
+
+-- 202510101200 up notx
+-- PRAGMA foreign_keys is a no-op inside a transaction.
+PRAGMA foreign_keys = OFF;
+PRAGMA user_version = 1;
+
+-- 202510101200 down notx
+PRAGMA user_version = 0;
+
+-- 20251010130000 users_email_index up
+CREATE INDEX IF NOT EXISTS users_email ON users(email);
+
+-- 20251010130000 users_email_index down
+DROP INDEX IF EXISTS users_email;
@@ -0,0 +1,17 @@
+-- 202510100900 up
+CREATE TABLE tags (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(100) NOT NULL
+);
+-- Tags are looked up by name.
+CREATE UNIQUE INDEX IF NOT EXISTS tags_name ON tags(name);
+ALTER TABLE tags ADD COLUMN description TEXT DEFAULT '';
+
+-- 202510100910 up
+INSERT INTO tags(name) VALUES('go');
+
+-- 202510100920 up
+CREATE VIEW tag_names AS SELECT name FROM tags;
+
+-- 202510100920 down
+DROP VIEW IF EXISTS tag_names;
@@ -1,7 +1,7 @@
 package rx
 
 import (
-	"bufio"
+	"cmp"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -16,6 +16,7 @@ import (
 	"unicode"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
 )
 
 func type2str[R Rowx](row R) string {
@@ -43,7 +44,10 @@ func TypeToSnake[R Rowx](row R) string {
 CamelToSnake is used to convert type names and structure fields to snake
 case table columns. We pass it to [reflectx.NewMapperFunc] together with
 [ReflectXTag]. For example the string `UserLastFiveComments` is transformed to
-`user_last_five_comments`.
+`user_last_five_comments`. The common initialisms (`ID`, `URL`, `HTTP`...)
+and the ones, registered by [RegisterInitialisms], are treated as one word:
+`HTTPServer` becomes `http_server` and `ProductSKUCode` becomes
+`product_sku_code`. So it reverts [SnakeToCamel].
 */
 func CamelToSnake(text string) string {
 	runes := []rune(text)
@@ -51,30 +55,84 @@ func CamelToSnake(text string) string {
 		return strings.ToLower(text)
 	}
 	var snake strings.Builder
-	var begins = true
-	var wasUpper = true
-	for _, r := range runes {
-		begins, wasUpper = lowerLetter(&snake, r, begins, wasUpper)
+	for i := 0; i < len(runes); {
+		word := initialismAt(runes, i)
+		if word == `` {
+			word = wordAt(runes, i)
+		}
+		// Digits and lowercase letters continue the previous word.
+		if i > 0 && unicode.IsUpper(runes[i]) {
+			snake.WriteRune(connector)
+		}
+		snake.WriteString(strings.ToLower(word))
+		i += len([]rune(word))
 	}
 	return snake.String()
 }
 
-const connector = '_'
+/*
+wordAt returns the word, which starts at runes[i]. A run of uppercase letters is
+one word, like an initialism, which is not registered - `SKU` in `SKUCode`.
+Otherwise the word ends before the next uppercase letter.
+*/
+func wordAt(runes []rune, i int) string {
+	end := i
+	for end < len(runes) && unicode.IsUpper(runes[end]) {
+		end++
+	}
+	if end-i > 1 {
+		if end < len(runes) && unicode.IsLower(runes[end]) {
+			// The last uppercase letter begins the next word.
+			end--
+		}
+		return string(runes[i:end])
+	}
+	for end < len(runes) && !unicode.IsUpper(runes[end]) {
+		end++
+	}
+	return string(runes[i:end])
+}
+
+// initialisms are registered by RegisterInitialisms. The keys are lowercased
+// and the values are uppercased.
+var initialisms = map[string]string{}
+
+/*
+RegisterInitialisms adds domain specific initialisms like `sku`, `vat`, `iban`
+to the common ones (`id`, `url`, `http`...), used by [SnakeToCamel] and
+[CamelToSnake], and thus by [Generate] and the mapping of fields to columns.
+So `vat_id` becomes `VATID` and `VATID` becomes `vat_id`. Call it once during
+initialisation of your application, before the first query and before
+generating code, because the mapping of every type is cached on first use.
+*/
+func RegisterInitialisms(words ...string) {
+	for _, w := range words {
+		initialisms[strings.ToLower(w)] = strings.ToUpper(w)
+	}
+}
 
-func lowerLetter(snake *strings.Builder, r rune, begins, wasUpper bool) (bool, bool) {
-	if unicode.IsUpper(r) && !begins {
-		snake.WriteRune(connector)
-		snake.WriteRune(unicode.ToLower(r))
-		return true, true // begins, wasUpper
+// initialismAt returns the longest common or registered initialism, which
+// starts at runes[i] and is followed by an uppercase letter, a digit or the end.
+func initialismAt(runes []rune, i int) (word string) {
+	if !unicode.IsUpper(runes[i]) {
+		return ``
 	}
-	if begins && wasUpper {
-		snake.WriteRune(unicode.ToLower(r))
-		return false, false // begins, wasUpper
+	for _, words := range []map[string]string{commonInitialisms, initialisms} {
+		for _, WORD := range words {
+			end := i + len([]rune(WORD))
+			if len(WORD) <= len(word) || end > len(runes) || string(runes[i:end]) != WORD {
+				continue
+			}
+			if end == len(runes) || unicode.IsUpper(runes[end]) || unicode.IsDigit(runes[end]) {
+				word = WORD
+			}
+		}
 	}
-	snake.WriteRune(r)
-	return begins, wasUpper
+	return word
 }
 
+const connector = '_'
+
 /*
 SnakeToCamel converts words from snake_case to CamelCase. It will be used to
 convert table_name to TableName and column_names to ColumnNames. This will be
@@ -97,22 +155,38 @@ func SnakeToCamel(snake_case_word string) string { //nolint:revive
 	return strings.Join(splitWords, ``)
 }
 
-// isCommonInitialism checks and returns the uppercased or properly modified word and `true`. if a word is not an initialism it returns it unchanged and returns `false`.
-func isCommonInitialism(word string) (string, bool) {
-	switch word {
-	case `acl`, `api`, `ascii`, `cpu`, `css`, `dns`, `eof`, `eta`, `gpu`,
+// structName returns the name of the struct for `table` without [TablePrefix]
+// and [TableSuffix].
+func structName(table string) string {
+	return SnakeToCamel(strings.TrimSuffix(strings.TrimPrefix(table, TablePrefix), TableSuffix))
+}
+
+// commonInitialisms are used by SnakeToCamel and CamelToSnake together with
+// the registered initialisms. The keys are lowercased.
+var commonInitialisms = func() map[string]string {
+	words := map[string]string{`oauth`: `OAuth`}
+	for _, w := range []string{`acl`, `api`, `ascii`, `cpu`, `css`, `dns`, `eof`, `eta`, `gpu`,
 		`guid`, `html`, `http`, `https`, `id`, `ip`, `json`, `lhs`, `os`, `qps`,
 		`ram`, `rhs`, `rpc`, `sla`, `smtp`, `sql`, `ssh`, `tcp`, `tls`, `ttl`,
 		`udp`, `ui`, `uid`, `uuid`, `uri`, `url`, `utf8`, `vm`, `xml`, `xmpp`,
-		`xsrf`, `xss`, `pid`:
-		return strings.ToUpper(word), true
-	case `oauth`:
-		return `OAuth`, true
-	case `OAuth`:
+		`xsrf`, `xss`, `pid`} {
+		words[w] = strings.ToUpper(w)
+	}
+	return words
+}()
+
+// isCommonInitialism checks and returns the uppercased or properly modified word and `true`. if a word is not an initialism it returns it unchanged and returns `false`.
+func isCommonInitialism(word string) (string, bool) {
+	if word == `OAuth` {
 		return word, true
-	default:
-		return word, false
 	}
+	if WORD, ok := commonInitialisms[word]; ok {
+		return WORD, true
+	}
+	if WORD, ok := initialisms[word]; ok {
+		return WORD, true
+	}
+	return word, false
 }
 
 type dir uint8
@@ -132,9 +206,19 @@ func (d dir) String() string {
 Migrate executes all not applied schema migrations with the given `direction`,
 found in `filePath` and stores in [MigrationsTable] the version, direction and
 file path of every applied migration. The migrations comments (headers) are
-expected to mach `^--\s*(\d{1,12})\s*(up|down)$`. For example: `--202506092333
-up`. All SQL statements in a migration are executed at once as one
-transaction.
+expected to mach `^--\s*(\d{1,14})\s*(?:([[:alpha:]][\w.-]*)\s+)?(up|down)(?:\s+(notx))?$`.
+For example: `--202506092333 up` or `--20250609123301 add_users_index up`. The
+version may be a full `YYYYmmddHHMMSS` timestamp and the optional
+human-readable label is recorded in [MigrationsTable]. The SQL statements of a
+migration are split with [SplitStatements] and executed one by one in one
+transaction. Some statements (e.g. certain PRAGMAs) must not be executed in
+a transaction. Annotate such migrations with `notx`: `--202506092333 up notx`.
+
+`filePath` may also be a directory. Then all `*.sql` files in it are read in
+lexical order. Besides the native format, files written for goose (`-- +goose
+Up`, `-- +goose Down`, `-- +goose NO TRANSACTION`) and golang-migrate pairs
+(`000001_name.up.sql` and `000001_name.down.sql`) are understood. For them the
+version and the label are taken from the file name.
 
 If the `direction` is `up`, all migrations in a file are applied in FIFO order.
 
@@ -148,27 +232,95 @@ some cases have to be applied in one run - a new release.
 Migrate is often followed by executing [Generate], if the schema of the
 database is modified - new columns or tables are added, modified or removed
 etc.
+
+If `dsn` differs from [DSN], Migrate uses its own connection, which is closed
+before it returns. Neither [DSN] nor [DB] are changed. On SQLite this
+connection waits up to [MigrateWait] for a locked database.
 */
 func Migrate(filePath, dsn, direction string) error {
+	return MigrateWith(MigrateOptions{FilePath: filePath, DSN: dsn, Direction: direction})
+}
+
+/*
+MigrateOptions are the arguments of [MigrateWith]. The hooks are optional. If a
+hook returns an error, no more migrations are applied and the error is
+returned. Every migration is passed to the hooks as the record, which is stored
+in [MigrationsTable] after it is applied, without the time in Applied.
+*/
+type MigrateOptions struct {
+	// BeforeAll is called with the migrations, which will be applied, before
+	// the first one.
+	BeforeAll func(db *sqlx.DB, pending []Migrations) error
+	// AfterAll is called with the applied migrations after the last one.
+	AfterAll func(db *sqlx.DB, applied []Migrations) error
+	// BeforeEach and AfterEach are called in the transaction of every
+	// migration before and after its statements are executed. `tx` is nil for
+	// migrations, annotated with `notx`.
+	BeforeEach func(tx *sqlx.Tx, m Migrations) error
+	AfterEach  func(tx *sqlx.Tx, m Migrations) error
+	// FilePath, DSN and Direction are the same as the arguments of [Migrate].
+	FilePath  string
+	DSN       string
+	Direction string
+}
+
+/*
+MigrateWith is like [Migrate], but calls the hooks from `opts`, so cache
+flushes, notifications or data validation can be plugged into the migration
+step of a deployment.
+
+	err := rx.MigrateWith(rx.MigrateOptions{
+		FilePath: `migrations`, DSN: dsn, Direction: `up`,
+		AfterEach: func(tx *sqlx.Tx, m rx.Migrations) error {
+			_, err := tx.Exec(`UPDATE users SET email = lower(email)`)
+			return err
+		},
+		AfterAll: func(_ *sqlx.DB, applied []rx.Migrations) error {
+			return notify(applied)
+		},
+	})
+*/
+func MigrateWith(opts MigrateOptions) error {
+	direction, dsn := opts.Direction, opts.DSN
 	if unknown(direction) {
 		return fmt.Errorf(`direction can be only '%s' or '%s'`, up, down)
 	}
-	/*
-		FIXME: dangerous!!! we assume here that DB() was not invoked yet and
-		Migrate is called from a main() function. What if it is called from a
-		long-running process? We need another separate singleDB.
-	*/
-	DSN = dsn
-	DB().MustExec(RenderSQLTemplate(`CREATE_MIGRATIONS_TABLE`, Map{`table`: MigrationsTable}))
+	if dsn != DSN {
+		dsn = sqliteWaitDSN(dsn)
+	}
+	db, disconnect, err := connectTo(dsn)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+	if err = ensureMigrationsTable(db); err != nil {
+		return err
+	}
 
-	migrations, err := parseMigrationFile(filePath)
+	migrations, err := parseMigrationFile(db, opts.FilePath)
 	if err != nil {
 		return err
 	}
 	if direction == down.String() {
 		slices.Reverse(migrations)
 	}
-
+	var pending []Migrations
+	total := 0
+	for _, v := range migrations {
+		if v.Direction == direction {
+			pending = append(pending, Migrations{
+				Version: v.Version, Direction: v.Direction, Label: v.Label, FilePath: v.File})
+			total += len(SplitStatements(v.Statements.String()))
+		}
+	}
+	if opts.BeforeAll != nil {
+		if err = opts.BeforeAll(db, pending); err != nil {
+			return err
+		}
+	}
+	p := startProgress(`MIGRATE `+opts.FilePath, `statements`, int64(total))
+	defer p.finish()
+	applied := 0
 	for _, v := range migrations {
 		statements := v.Statements.String()
 		if v.Direction != direction {
@@ -176,53 +328,177 @@ func Migrate(filePath, dsn, direction string) error {
 			continue
 		}
 		Logger.Infof(`Applying %s %s: %s...`, v.Version, v.Direction, substr(statements, 30))
+		if err = applyMigration(db, &v, pending[applied], opts, p); err != nil {
+			return err
+		}
+		applied++
+	}
+	if opts.AfterAll != nil {
+		return opts.AfterAll(db, pending)
+	}
+	return nil
+}
 
-		if err = multiExec(DB(), statements); err != nil {
+/*
+applyMigration executes the statements of `v` and stores `record` in
+[MigrationsTable] in one transaction, unless `v` must be executed outside of
+a transaction.
+*/
+func applyMigration(db *sqlx.DB, v *migration, record Migrations, opts MigrateOptions, p *progress) error {
+	statements := SplitStatements(v.Statements.String())
+	if v.NoTx {
+		if opts.BeforeEach != nil {
+			if err := opts.BeforeEach(nil, record); err != nil {
+				return err
+			}
+		}
+		if err := execStatements(db, statements, p); err != nil {
 			return err
 		}
-		if _, err = NewRx(Migrations{
-			Version:   v.Version,
-			Direction: v.Direction,
-			FilePath:  filePath}).Insert(); err != nil {
+		if opts.AfterEach != nil {
+			if err := opts.AfterEach(nil, record); err != nil {
+				return err
+			}
+		}
+		_, err := newRxOn(db, record).Insert()
+		return err
+	}
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	// The rollback will be ignored if the tx has been committed already.
+	defer func() { _ = tx.Rollback() }()
+	if opts.BeforeEach != nil {
+		if err = opts.BeforeEach(tx, record); err != nil {
 			return err
 		}
 	}
-	return err
+	if err = execStatements(tx, statements, p); err != nil {
+		return err
+	}
+	if opts.AfterEach != nil {
+		if err = opts.AfterEach(tx, record); err != nil {
+			return err
+		}
+	}
+	if _, err = newRxOn(tx, record).Insert(); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func substr(str string, lenChars int) string {
-	var newStr strings.Builder
-	for i, char := range str {
-		newStr.WriteRune(char)
-		if i == lenChars-1 {
-			break
+// execStatements executes the statements one by one and reports them to `p`.
+func execStatements(ex sqlx.Execer, statements []string, p *progress) error {
+	for _, s := range statements {
+		if _, err := ex.Exec(s); err != nil {
+			return fmt.Errorf("%w\nin statement:\n%s", err, s)
 		}
+		p.add(1)
 	}
-	return newStr.String()
+	return nil
 }
 
-func unknown(direction string) bool {
-	return direction != up.String() && direction != down.String()
+/*
+MigrateWait is how long [Migrate] waits for a locked SQLite database (e.g. held
+by the running application) before it fails. Migrations are applied in `BEGIN
+IMMEDIATE` transactions, so they do not fail immediately with "database is
+locked", when a lock can not be upgraded. Both are set with the parameters
+`_busy_timeout` and `_txlock` of the DSN, unless it has them already.
+*/
+var MigrateWait = 5 * time.Second
+
+// sqliteWaitDSN adds to `dsn` the parameters, described in [MigrateWait], if
+// it is for the driver `sqlite3`.
+func sqliteWaitDSN(dsn string) string {
+	if driver, _ := driverFor(dsn); driver != `sqlite3` {
+		return dsn
+	}
+	params := make([]string, 0, 2)
+	// Also matches the alias `_timeout`.
+	if !strings.Contains(dsn, `_timeout=`) {
+		params = append(params, sprintf(`_busy_timeout=%d`, MigrateWait.Milliseconds()))
+	}
+	if !strings.Contains(dsn, `_txlock=`) {
+		params = append(params, `_txlock=immediate`)
+	}
+	if len(params) == 0 {
+		return dsn
+	}
+	if strings.Contains(dsn, `?`) {
+		return dsn + `&` + strings.Join(params, `&`)
+	}
+	return dsn + `?` + strings.Join(params, `&`)
 }
 
 /*
-multiExec was stollen from sqlx_test.go and slightly modified as a poor's man
-migration. It executes all stements, found in a big multy query string, at once
-and in one transaction.
+ensureMigrationsTable creates [MigrationsTable] if it does not exist and adds
+to it columns, which were introduced later.
 */
-func multiExec(db *sqlx.DB, query string) (err error) {
-	tx := db.MustBegin()
-	// The rollback will be ignored if the tx has been committed already.
-	defer func() { _ = tx.Rollback() }()
-	_, err = tx.Exec(query)
+func ensureMigrationsTable(db *sqlx.DB) error {
+	create, err := RenderSQLTemplateE(`CREATE_MIGRATIONS_TABLE`, Map{`table`: MigrationsTable})
 	if err != nil {
 		return err
 	}
-
-	if err = tx.Commit(); err != nil {
+	if _, err = db.Exec(create); err != nil {
 		return err
 	}
-	return
+	for _, column := range []string{`label`, `superseded_by`} {
+		if _, err := db.Exec(sprintf(`SELECT %s FROM %s LIMIT 0`, column, MigrationsTable)); err == nil {
+			continue
+		}
+		Logger.Infof(`Adding column %s to %s...`, column, MigrationsTable)
+		add, err := RenderSQLTemplateE(`ADD_MIGRATIONS_`+strings.ToUpper(column), Map{`table`: MigrationsTable})
+		if err != nil {
+			return err
+		}
+		if _, err = db.Exec(add); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+connectTo returns [DB], if `dsn` is the same as [DSN]. Otherwise it opens a
+new connection to `dsn`, so [Migrate] and [Generate] can be called from a
+long-running process, which already uses [DB] with another database. The
+returned function closes the new connection and must always be called.
+*/
+func connectTo(dsn string) (db *sqlx.DB, disconnect func(), err error) {
+	if dsn == DSN {
+		return DB(), func() {}, nil
+	}
+	Logger.Debugf("Connecting to database '%s'...", loggableDSN(dsn))
+	if db, err = sqlx.Connect(driverFor(dsn)); err != nil {
+		return nil, nil, err
+	}
+	db.Mapper = reflectx.NewMapperFunc(ReflectXTag, CamelToSnake)
+	return db, func() {
+		if err := db.Close(); err != nil {
+			Logger.Errorf(`connection closed unsuccesfully: %s`, err.Error())
+		}
+	}, nil
+}
+
+// newRxOn returns a new [Rx], which executes its queries on `ex`.
+func newRxOn[R Rowx](ex Ext, rows ...R) *Rx[R] {
+	return &Rx[R]{data: rows, r: nilRowx[R](), queryer: ex}
+}
+
+func substr(str string, lenChars int) string {
+	var newStr strings.Builder
+	for i, char := range str {
+		newStr.WriteRune(char)
+		if i == lenChars-1 {
+			break
+		}
+	}
+	return newStr.String()
+}
+
+func unknown(direction string) bool {
+	return direction != up.String() && direction != down.String()
 }
 
 // Migrations is an object, mapped to [MigrationsTable].
@@ -231,6 +507,10 @@ type Migrations struct {
 	Version   string
 	Direction string
 	FilePath  string
+	Label     string
+	// SupersededBy is the version of the baseline migration, produced by
+	// [Squash], which replaced this one.
+	SupersededBy string
 }
 
 // Table returns the table for [Migrations].
@@ -238,78 +518,214 @@ func (r *Migrations) Table() string {
 	return MigrationsTable
 }
 
+/*
+AppliedMigrations returns the migrations, which are in effect in the database,
+connected via [DB] - applied `up`, not reverted by their `down` migrations and
+not superseded by a baseline (see [Squash]), ordered by version. Use it to show
+the state of the schema in admin pages.
+*/
+func AppliedMigrations() ([]Migrations, error) {
+	applied, err := NewRx[Migrations]().SelectAll(
+		sprintf(`direction = :up AND superseded_by = '' AND
+			version NOT IN (SELECT version FROM %s WHERE direction = :down)`,
+			MigrationsTable), Map{`up`: up.String(), `down`: down.String()})
+	if err != nil {
+		return nil, err
+	}
+	// Versions may have different lengths - YYYYmmddHHMM and YYYYmmddHHMMSS.
+	slices.SortFunc(applied, func(a, b Migrations) int {
+		return cmp.Or(cmp.Compare(len(a.Version), len(b.Version)), strings.Compare(a.Version, b.Version))
+	})
+	return applied, nil
+}
+
+// LastAppliedVersion returns the greatest version of [AppliedMigrations] or
+// an empty string, if no migrations are in effect.
+func LastAppliedVersion() (string, error) {
+	applied, err := AppliedMigrations()
+	if err != nil || len(applied) == 0 {
+		return ``, err
+	}
+	return applied[len(applied)-1].Version, nil
+}
+
 type migration struct {
 	Version    string
 	Direction  string
+	Label      string
+	File       string
 	Statements strings.Builder
+	// NoTx is true for migrations, which must be executed outside of a
+	// transaction.
+	NoTx bool
 }
 
-func parseMigrationFile(filePath string) (migrations []migration, err error) {
-	fh, err := safeOpen(filePath)
+func parseMigrationFile(db *sqlx.DB, filePath string) ([]migration, error) {
+	all, err := readMigrations(filePath)
 	if err != nil {
-		return migrations, err
-	}
-	defer fh.Close()
-
-	scanner := bufio.NewScanner(fh)
-	migrations = make([]migration, 0)
-	versionIsApplied := false
-	currentVersion := ``
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if version, direction := parseMigrationHeader(line); version != `` && direction != `` {
-			v, err := NewRx[Migrations]().Get(
-				`version=:ver AND direction =:dir`, Map{`ver`: version, `dir`: direction})
-			// If this migration is not found in the applied migrations, we
-			// must start collecting its lines to apply it.
-			if err != nil && errors.Is(err, sql.ErrNoRows) {
-				versionIsApplied = false
-				currentVersion = version
-				migrations = append(migrations,
-					migration{Version: currentVersion, Direction: direction})
-			} else if err == nil {
-				Logger.Infof(`applied "%s %s" during a previous run...`, v.Version, v.Direction)
-				versionIsApplied = true
-			}
+		return nil, err
+	}
+	migrations := make([]migration, 0, len(all))
+	for i := range all {
+		v, err := newRxOn[Migrations](db).Get(
+			`version=:ver AND direction =:dir`, Map{`ver`: all[i].Version, `dir`: all[i].Direction})
+		// If this migration is not found in the applied migrations, we
+		// must apply it.
+		if errors.Is(err, sql.ErrNoRows) {
+			migrations = append(migrations, migration{})
+			copyMigration(&migrations[len(migrations)-1], &all[i])
 			continue
 		}
-		// Do not collect anything until a header is found or if this verion is
-		// already applied.
-		if currentVersion == `` || versionIsApplied {
-			continue
+		if err != nil {
+			return nil, err
 		}
-		// else collect migrations
-		migrations[len(migrations)-1].Statements.WriteString(line)
-		migrations[len(migrations)-1].Statements.WriteString("\n")
+		Logger.Infof(`applied "%s %s" during a previous run...`, v.Version, v.Direction)
 	}
 	return migrations, nil
 }
 
+/*
+safeOpen opens `filePath` after checking that it is within one of
+[AllowedRoots]. If [AllowedRoots] is empty, any path is allowed.
+*/
 func safeOpen(filePath string) (*os.File, error) {
-	filePath, _ = filepath.Abs(filePath)
-	cwd, _ := os.Getwd()
-	if !strings.HasPrefix(filePath, cwd) {
-		Logger.Panicf(`%s is unsafe. Cannot continue...`, filePath)
+	filePath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !allowedPath(filePath) {
+		return nil, fmt.Errorf(`%s is outside of %v: %w`, filePath, AllowedRoots, ErrUnsafePath)
 	}
-	// Logger.Debugf(`Opening a safe path %s`, filePath)
 	return os.Open(filePath) //nolint:gosec // Abs calls Clean on result.
 }
 
-var migrationHeader = regexp.MustCompile(`^--\s*(\d{1,12})\s*(up|down)$`)
+// allowedPath reports whether the absolute `filePath` is within one of
+// [AllowedRoots].
+func allowedPath(filePath string) bool {
+	if len(AllowedRoots) == 0 {
+		return true
+	}
+	for _, root := range AllowedRoots {
+		root, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(root, filePath)
+		if err == nil && rel != `..` && !strings.HasPrefix(rel, `..`+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+var migrationHeader = regexp.MustCompile(
+	`^--\s*(\d{1,14})\s*(?:([[:alpha:]][\w.-]*)\s+)?(up|down)(?:\s+(notx))?$`)
 
-func parseMigrationHeader(line string) (version, direction string) {
+func parseMigrationHeader(line string) (version, label, direction string, noTx bool) {
 	matches := migrationHeader.FindStringSubmatch(line)
-	if len(matches) == 3 {
-		return matches[1], matches[2]
+	if len(matches) == 5 {
+		return matches[1], matches[2], matches[3], matches[4] != ``
 	}
 	return
 }
 
+/*
+SuggestDown reads the migrations file `filePath` and for every `up` migration,
+which has no `down` counterpart, generates a `down` migration, if all its
+statements are reversible - CREATE TABLE, CREATE VIEW, CREATE INDEX and ALTER
+TABLE ... ADD COLUMN. The suggested `down` migrations are returned as a string
+to be reviewed and appended to the file. Migrations with irreversible
+statements are skipped with a warning. ALTER TABLE ... ADD CONSTRAINT, PRIMARY
+KEY, UNIQUE, FOREIGN KEY or CHECK are not reversible.
+
+The suggested statements are in the SQLite dialect. For example MySQL needs
+`DROP INDEX idx ON table` instead of `DROP INDEX IF EXISTS idx`.
+*/
+func SuggestDown(filePath string) (string, error) {
+	migrations, err := readMigrations(filePath)
+	if err != nil {
+		return ``, err
+	}
+	downs := map[string]bool{}
+	for i := range migrations {
+		if migrations[i].Direction == down.String() {
+			downs[migrations[i].Version] = true
+		}
+	}
+	var suggested strings.Builder
+	for i := range migrations {
+		v := &migrations[i]
+		if v.Direction != up.String() || downs[v.Version] {
+			continue
+		}
+		statements, ok := reverseStatements(v.Statements.String())
+		if !ok {
+			Logger.Warnf(`Cannot suggest down for %s: %s`, v.Version, statements)
+			continue
+		}
+		suggested.WriteString(sprintf("\n-- %s down\n%s", v.Version, statements))
+	}
+	return suggested.String(), nil
+}
+
+// reversibleStatements are tried in order. A match with an empty `down` makes
+// the statement not reversible.
+var reversibleStatements = []struct {
+	re   *regexp.Regexp
+	down string
+}{
+	{regexp.MustCompile(`(?is)^CREATE\s+(?:TEMP(?:ORARY)?\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([\w."]+)`),
+		`DROP TABLE IF EXISTS $1;`},
+	{regexp.MustCompile(`(?is)^CREATE\s+(?:TEMP(?:ORARY)?\s+)?VIEW\s+(?:IF\s+NOT\s+EXISTS\s+)?([\w."]+)`),
+		`DROP VIEW IF EXISTS $1;`},
+	{regexp.MustCompile(`(?is)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(?:IF\s+NOT\s+EXISTS\s+)?([\w."]+)`),
+		`DROP INDEX IF EXISTS $1;`},
+	{regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+[\w."]+\s+ADD\s+(?:CONSTRAINT|PRIMARY|UNIQUE|FOREIGN|CHECK)\b`),
+		``},
+	{regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+([\w."]+)\s+ADD\s+(?:COLUMN\s+)?([\w"]+)`),
+		`ALTER TABLE $1 DROP COLUMN $2;`},
+}
+
+/*
+reverseStatements returns the statements, reverting the given ones in reverse
+order and true. If a statement is not reversible, it is returned with false.
+The statements are split by [SplitStatements], so semicolons in literals do not
+end them.
+*/
+func reverseStatements(statements string) (string, bool) {
+	reversed := make([]string, 0)
+	var code strings.Builder
+	for line := range strings.Lines(statements) {
+		if !strings.HasPrefix(strings.TrimSpace(line), `--`) {
+			code.WriteString(line)
+		}
+	}
+	for _, stmt := range SplitStatements(code.String()) {
+		stmt = strings.TrimSpace(stmt)
+		ok := false
+		for _, r := range reversibleStatements {
+			if match := r.re.FindStringSubmatchIndex(stmt); match != nil {
+				if r.down != `` {
+					reversed = append(reversed, string(r.re.ExpandString(nil, r.down, stmt, match)))
+					ok = true
+				}
+				break
+			}
+		}
+		if !ok {
+			return substr(stmt, 60), false
+		}
+	}
+	slices.Reverse(reversed)
+	return strings.Join(reversed, "\n") + "\n", true
+}
+
 /*
 Generate generates structures for tables, found in database, pointed to by
 `dsn` and dumps them to a given `packagePath` directory. Returns an error if
 unsuccessful at any point of the execution. The name of the last directory in
 the path is used as package name. The directory must exist already.
+Just like [Migrate], Generate does not change [DSN] or [DB].
 
 `tables` is expected to contain comma-separated tablenames, for which
 structures will be generated. If `tables` is an empty string, structures for
@@ -322,67 +738,96 @@ the next run of this function to re-map the potentially migrated to a new state
 schema to Go structs.
 */
 func Generate(dsn string, packagePath string, tables string) error {
-	DSN = dsn
+	db, disconnect, err := connectTo(dsn)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+	return generate(db, dsn, packagePath, tables)
+}
+
+/*
+GenerateFrom is like [Generate], but uses an already opened connection `db`,
+so it can be embedded in other tools. The query for introspecting the database
+is chosen by the name of the driver of `db` - `SELECT_TABLE_INFO_<driver>` from
+[QueryTemplates]. If there is no such template, `SELECT_TABLE_INFO`, using the
+standard `information_schema`, is used (e.g. for PostgreSQL and MySQL).
+*/
+func GenerateFrom(db *sqlx.DB, packagePath string, tables string) error {
+	return generate(db, sprintf(`a %s connection`, db.DriverName()), packagePath, tables)
+}
+
+func generate(db *sqlx.DB, database, packagePath, tables string) error {
 	dh, err := safeOpen(packagePath)
 	if err != nil {
 		return fmt.Errorf("%w. The directory must exist already", err)
 	}
 	defer dh.Close()
 
-	info, err := collectTableColumnInfo(tables)
+	dirName := dh.Name()
+	// TODO: Generate also a file for views.
+	files, err := GenerateFiles(GenerateOptions{DB: db, Package: filepath.Base(dirName), Tables: tables,
+		Database: database, Tests: GenerateTests, BinaryUUIDs: GenerateBinaryUUIDs, DTOs: GenerateDTOs,
+		Handlers: GenerateHandlers})
 	if err != nil {
 		return err
 	}
-	var structsFileString strings.Builder
-	dirName := dh.Name()
-	preparePackageHeaderForGeneratedStructs(dirName, &structsFileString)
-	prepareGeneratedStructs(info, &structsFileString)
-	// Logger.Debugf(`Package header and body: %+s`, structsFileString.String())
-	// Write the prepared code with generated structures to file.
-	sep := string(os.PathSeparator)
-	path := strings.Split(dirName, sep)
-	packageName := path[len(path)-1]
-	// TODO: Generate also a file for views.
-	tablesFileName := dirName + sep + packageName + "_tables.go"
 	// Now we will know if we are ran for the first time for this directory or not.
-	files, _ := dh.ReadDir(0)
-	regenerated := false
-	packageFileName := packageName + ".go"
-	rePrefix := ``
+	existing, _ := dh.ReadDir(0)
 	for _, f := range files {
-		if f.Name() == packageFileName {
-			regenerated = true
+		fileName := filepath.Join(dirName, f.Name)
+		rePrefix := ``
+		if slices.ContainsFunc(existing, func(e os.DirEntry) bool { return e.Name() == f.Name }) {
+			if !f.Overwrite {
+				continue
+			}
 			rePrefix = `re-`
 		}
+		Logger.Infof(`%sgenerating %s...`, rePrefix, fileName)
+		if err = os.WriteFile(fileName, f.Content, 0600); err != nil {
+			return fmt.Errorf("os.WriteFile: %w", err)
+		}
 	}
-	Logger.Infof(`%sgenerating %s...`, rePrefix, tablesFileName)
-	if err = os.WriteFile(tablesFileName, []byte(structsFileString.String()), 0600); err != nil {
-		return fmt.Errorf("os.WriteFile: %w", err)
-	}
-	if !regenerated {
-		modelAsString := prepareModelFileContents(packageName)
-		modelFileName := dirName + sep + packageFileName
-		Logger.Infof(`generating %s...`, modelFileName)
-		return os.WriteFile(modelFileName, []byte(modelAsString), 0600)
-	}
-	return err
+	return nil
+}
+
+func collectTableColumnInfo(db *sqlx.DB, tables string) (info []columnInfo, err error) {
+	return collectColumnInfo(db, tables, `TABLE_TYPE`)
 }
 
-func collectTableColumnInfo(tables string) (info []columnInfo, err error) {
+// collectColumnInfo collects the columns of tables or views, depending on
+// `typeKey` - `TABLE_TYPE` or `VIEW_TYPE`.
+func collectColumnInfo(db *sqlx.DB, tables, typeKey string) (info []columnInfo, err error) {
 	tNames := strings.Split(tables, `,`)
 	for i, tName := range tNames {
 		tNames[i] = `'` + strings.TrimSpace(tName) + `'`
 	}
-	sql := QueryTemplates[`SELECT_TABLE_INFO_sqlite3`].(string)
+	driver := db.DriverName()
+	sql, err := queryTemplate(dialectKey(`SELECT_TABLE_INFO`, driver))
+	if err != nil {
+		return nil, err
+	}
 	var andTnameIn = ``
 	if tables != `` {
 		andTnameIn = ` AND t.name IN(` + strings.Join(tNames, `,`) + `)`
 	}
-	sql = replace(sql, `${`, `}`, map[string]any{`and_t_name_in`: andTnameIn})
+	sql, err = replaceE(sql, map[string]any{
+		`and_t_name_in`:  andTnameIn,
+		`current_schema`: QueryTemplates[dialectKey(`CURRENT_SCHEMA`, driver)],
+		`table_type`:     QueryTemplates[dialectKey(typeKey, driver)],
+	})
+	if err != nil {
+		return nil, err
+	}
 	info = []columnInfo{}
-	if err = DB().Select(&info, sql, MigrationsTable); err != nil {
+	if err = db.Select(&info, db.Rebind(sql), MigrationsTable); err != nil {
 		return info, err
 	}
+	if driver == `clickhouse` {
+		for i := range info {
+			info[i].CType = clickHouseType(info[i].CType)
+		}
+	}
 	return info, err
 }
 
@@ -398,11 +843,11 @@ add your custom code here.
 */
 `
 
-func prepareModelFileContents(packageName string) string {
-	return replace(modelHeader, `${`, `}`, map[string]any{
+func prepareModelFileContents(tpl, database, packageName string) string {
+	return replace(tpl, `${`, `}`, map[string]any{
 		`package`:  packageName,
 		`Package`:  SnakeToCamel(packageName),
-		`database`: DSN,
+		`database`: database,
 	})
 }
 
@@ -412,26 +857,35 @@ This file will be regenerated each time you run [rx.Generate]
 */
 
 import (
+	"bytes"
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"reflect"
 	"time"
 	
 	"github.com/kberov/rowx/rx"
 )
 
+// SchemaFingerprint identifies the schema of ${database}, from which
+// this file was generated. See [rx.CheckFingerprint].
+const SchemaFingerprint = "${fingerprint}"
 `
 
 // preparePackageHeaderForGeneratedStructs only iterates trough the rows to prepare the Rowx
 // constraint. It allso uses the last folder from packagePath for package name.
-// The produced string is added to fileString.
-// TODO: Import only used packages. Until then we use goimports to clean unused packages.
-func preparePackageHeaderForGeneratedStructs(packagePath string, fileString *strings.Builder) {
+// The produced string is added to fileString. The unused imports are removed
+// later by [GenerateFiles].
+func preparePackageHeaderForGeneratedStructs(tpl, database, packagePath, fingerprint string, fileString *strings.Builder) {
 	pathToPackage := strings.Split(packagePath, string(os.PathSeparator))
 	packageName := pathToPackage[len(pathToPackage)-1]
 	fileString.WriteString(
-		replace(packageHeader, `${`, `}`, Map{
-			`package`:  packageName,
-			`Package`:  SnakeToCamel(packageName),
-			`database`: DSN,
+		replace(tpl, `${`, `}`, Map{
+			`package`:     packageName,
+			`Package`:     SnakeToCamel(packageName),
+			`database`:    database,
+			`fingerprint`: fingerprint,
 		}),
 	)
 }
@@ -461,6 +915,25 @@ func (u *${TableName}) Columns() []string {
 	return []string{${column_names}
 	}
 }
+
+// Clone returns a deep copy of ${TableName}. Byte slices are copied too.
+func (u *${TableName}) Clone() *${TableName} {
+	c := *u${clone}
+	return &c
+}
+
+// Equal reports whether u and other have equal values in all columns. Null
+// values are equal, regardless of the values, wrapped in them.
+func (u *${TableName}) Equal(other *${TableName}) bool {
+	if u == nil || other == nil {
+		return u == other
+	}
+	return ${equal}
+}
+
+// ${TableName}Indexes describes the indexes on table ${table_name}.
+var ${TableName}Indexes = []rx.Index{${indexes}
+}
 `
 
 func appendRowToLastStructTemplate(structsStashes *[]Map, i int, columns []columnInfo) {
@@ -471,7 +944,7 @@ func appendRowToLastStructTemplate(structsStashes *[]Map, i int, columns []colum
 		// SA4006: this value of structsStashes is never used (staticcheck)
 		//nolint:staticcheck
 		*structsStashes = append(*structsStashes, Map{
-			`TableName`:         SnakeToCamel(columns[i].TableName),
+			`TableName`:         structName(columns[i].TableName),
 			`table_name`:        columns[i].TableName,
 			`fieldsWithGoTypes`: &fieldsWithGoTypes,
 			`fields`:            sql2GoTypeAndTag(columns[i], &fieldsWithGoTypes),
@@ -489,7 +962,7 @@ func appendRowToLastStructTemplate(structsStashes *[]Map, i int, columns []colum
 		// SA4006: this value of structsStashes is never used (staticcheck)
 		//nolint:staticcheck
 		*structsStashes = append(*structsStashes, Map{
-			`TableName`:         SnakeToCamel(columns[i].TableName),
+			`TableName`:         structName(columns[i].TableName),
 			`table_name`:        columns[i].TableName,
 			`fieldsWithGoTypes`: &fieldsWithGoTypes,
 			`fields`:            sql2GoTypeAndTag(columns[i], &fieldsWithGoTypes),
@@ -504,7 +977,19 @@ func appendRowToLastStructTemplate(structsStashes *[]Map, i int, columns []colum
 }
 
 type fieldWithGoType struct {
-	field, goType string
+	field, goType, name string
+	// enum is the name of the type, generated for the column by
+	// prepareEnums, if any.
+	enum string
+}
+
+// layoutType returns the type, which has the same alignment and size as the
+// type of the field.
+func (f fieldWithGoType) layoutType() string {
+	if f.enum == `` {
+		return f.goType
+	}
+	return strings.Replace(f.goType, f.enum, `string`, 1)
 }
 
 // sql2GoTypeAndTag converts SQL column types to Go types. Case statemnets
@@ -515,7 +1000,35 @@ func sql2GoTypeAndTag(column columnInfo, fieldsSlice *[]fieldWithGoType) string
 	var colType = strings.ToLower(strings.TrimSpace(strings.Split(column.CType, "(")[0]))
 	var goType string
 
+	if strings.HasSuffix(colType, "[]") { // DuckDB LIST, e.g. INTEGER[]
+		colType = "list"
+	}
+	if column.Strict { // SQLite STRICT tables have only 64-bit numbers.
+		switch colType {
+		case "int":
+			colType = "integer"
+		case "real":
+			colType = "double"
+		}
+	}
 	switch colType {
+	case "any": // SQLite STRICT
+		// NULL is scanned as nil.
+		goType = "any"
+	case "list":
+		// A NULL list is scanned as a nil slice.
+		goType = "[]any"
+	case "hugeint", "uhugeint": // DuckDB
+		// NULL is scanned as a nil pointer.
+		goType = "*big.Int"
+	case "utinyint":
+		goType = sql2IfNullableGoType(column, "uint8")
+	case "usmallint":
+		goType = sql2IfNullableGoType(column, "uint16")
+	case "uinteger":
+		goType = sql2IfNullableGoType(column, "uint32")
+	case "ubigint":
+		goType = sql2IfNullableGoType(column, "uint64")
 	case "user-defined", "enum":
 		goType = sql2IfNullableGoType(column, "string")
 	case "boolean", "bool":
@@ -539,7 +1052,10 @@ func sql2GoTypeAndTag(column columnInfo, fieldsSlice *[]fieldWithGoType) string
 	case "bytea",
 		"binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob": // MySQL
 		goType = sql2IfNullableGoType(column, "[]byte")
-	case "text",
+		if column.BinaryUUID && strings.HasSuffix(strings.ReplaceAll(column.CType, ` `, ``), `(16)`) {
+			goType = sql2IfNullableGoType(column, "rx.UUID")
+		}
+	case "text", "uuid",
 		"character", "bpchar",
 		"character varying", "varchar", "nvarchar",
 		"tsvector", "bit", "bit varying", "varbit",
@@ -558,16 +1074,76 @@ func sql2GoTypeAndTag(column columnInfo, fieldsSlice *[]fieldWithGoType) string
 		goType = sql2IfNullableGoType(column, "string")
 	}
 	// Logger.Debugf("goType:%s", goType)
-	var neededTag string
+	var enumType string
+	if strings.TrimSuffix(strings.TrimPrefix(goType, `sql.Null[`), `]`) == `string` {
+		if e := enumOf(column); e != nil {
+			enumType = e.Name
+			goType = strings.Replace(goType, `string`, enumType, 1)
+		}
+	}
+	var neededTag, comment string
 	columnName := strings.ToLower(column.CName)
-	if columnName == `id` {
+	fieldName := SnakeToCamel(columnName)
+	if column.Generated {
+		// Generated columns can not be inserted or updated.
+		neededTag = " `" + ReflectXTag + `:"` + columnName + `,readonly"` + "`"
+		comment = ` // Generated always as (` + generatedExpression(column.SQL, column.CName) + `).`
+	} else if columnName == `id` && !column.NoAuto {
 		neededTag = " `" + ReflectXTag + `:"` + columnName + `,auto"` + "`"
+	} else if CamelToSnake(fieldName) != columnName {
+		// E.g. a column `uid` becomes a field UID, which would be mapped to
+		// `u_id`.
+		neededTag = " `" + ReflectXTag + `:"` + columnName + `"` + "`"
 	}
-	field := "\t" + SnakeToCamel(columnName) + ` ` + goType + neededTag + "\n"
-	*fieldsSlice = append(*fieldsSlice, fieldWithGoType{field, goType})
+	field := "\t" + fieldName + ` ` + goType + neededTag + comment + "\n"
+	*fieldsSlice = append(*fieldsSlice, fieldWithGoType{field, goType, fieldName, enumType})
 	return field
 }
 
+/*
+generatedExpression returns the expression of the generated column `column` from
+the statement `create`, which created its table. Returns an empty string if the
+column definition is not found.
+*/
+func generatedExpression(create, column string) string {
+	def := regexp.MustCompile("(?is)[(,]\\s*[\"`\\[]?" + regexp.QuoteMeta(column) +
+		"[\"`\\]]?\\s(?:[^,(]|\\([^)]*\\))*?\\bAS\\s*\\(")
+	loc := def.FindStringIndex(create)
+	if loc == nil {
+		return ``
+	}
+	expr, _ := parenthesized(create, loc[1]-1)
+	return strings.TrimSpace(expr)
+}
+
+/*
+parenthesized returns the text between the parenthesis at `open` in `s` and
+its matching closing parenthesis and the position after the latter. Parentheses
+in quoted strings and identifiers are skipped.
+*/
+func parenthesized(s string, open int) (string, int) {
+	depth := 0
+	var quote byte
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth--; depth == 0 {
+				return s[open+1 : i], i + 1
+			}
+		}
+	}
+	return s[open+1:], len(s)
+}
+
 /*
 sql2IfNullableGoType decides what will be the final type for the field in the
 Go struct. We may add here some heuristics applied on the data and found check
@@ -584,7 +1160,7 @@ func sql2IfNullableGoType(column columnInfo, defaultType string) string {
 	return "sql.Null[" + defaultType + "]"
 }
 
-func prepareGeneratedStructs(columns []columnInfo, fileString *strings.Builder) {
+func prepareGeneratedStructs(tpl string, columns []columnInfo, indexes map[string][]Index, fileString *strings.Builder) {
 	structsInfo := make([]Map, 0, 10)
 
 	for i := range columns {
@@ -593,7 +1169,9 @@ func prepareGeneratedStructs(columns []columnInfo, fileString *strings.Builder)
 	// Logger.Debugf(`structsInfo: %+v`, structsInfo)
 	for _, v := range structsInfo {
 		allignStructFields(v)
-		fileString.WriteString(replace(structTemplate, `${`, `}`, v))
+		cloneAndEqual(v)
+		v[`indexes`] = renderIndexes(indexes[v[`table_name`].(string)])
+		fileString.WriteString(replace(tpl, `${`, `}`, v))
 	}
 }
 
@@ -604,18 +1182,35 @@ type columnInfo struct {
 	// CType sql.ColumnType
 	CType        string
 	DefaultValue sql.NullString
-	CID          uint8
+	CID          uint8 `rx:"c_id"`
 	PK           uint8
 	NotNull      bool
+	// Generated is true for generated columns. Their expression is parsed
+	// from SQL.
+	Generated bool
+	// Strict and WithoutRowid describe SQLite STRICT and WITHOUT ROWID
+	// tables.
+	Strict       bool `rx:"strict"`
+	WithoutRowid bool `rx:"without_rowid"`
+	// NoAuto is true for columns, which values the database does not
+	// assign, e.g. an SQLite primary key, which is not an alias of the rowid.
+	NoAuto bool `rx:"no_auto"`
+	// TableKind is `virtual` for SQLite virtual tables.
+	TableKind string `rx:"table_kind"`
+	// BinaryUUID maps BLOB(16) and BINARY(16) columns to [UUID]. It is
+	// not selected, but set from [GenerateOptions.BinaryUUIDs].
+	BinaryUUID bool `rx:"-"`
 }
 
 func allignStructFields(structInfo Map) {
 	columns := *(structInfo[`fieldsWithGoTypes`].(*[]fieldWithGoType))
-	sort.Slice(columns, func(i, j int) bool {
-		ai := alignTable[columns[i].goType]
-		aj := alignTable[columns[j].goType]
+	// Keep the order of the columns for fields with equal alignment and size,
+	// so the generated code does not change between runs.
+	sort.SliceStable(columns, func(i, j int) bool {
+		ai := alignTable[columns[i].layoutType()]
+		aj := alignTable[columns[j].layoutType()]
 		if ai == aj {
-			return sizeTable[columns[i].goType] > sizeTable[columns[j].goType]
+			return sizeTable[columns[i].layoutType()] > sizeTable[columns[j].layoutType()]
 		}
 		return ai > aj
 	})
@@ -627,6 +1222,49 @@ func allignStructFields(structInfo Map) {
 	structInfo[`fields`] = alignedFields.String()
 }
 
+/*
+cloneAndEqual prepares the bodies of the methods Clone and Equal for a struct.
+Fields, which share memory, are copied, and wrapped values of Null fields are
+compared only if the fields are valid.
+*/
+func cloneAndEqual(structInfo Map) {
+	var clone strings.Builder
+	equal := make([]string, 0, 10)
+	for _, f := range *(structInfo[`fieldsWithGoTypes`].(*[]fieldWithGoType)) {
+		goType, value := f.goType, ``
+		if inner, ok := strings.CutPrefix(goType, `sql.Null[`); ok {
+			goType, value = strings.TrimSuffix(inner, `]`), `.V`
+		}
+		a, b := `u.`+f.name+value, `other.`+f.name+value
+		var eq string
+		switch goType {
+		case `[]byte`:
+			clone.WriteString(sprintf("\n\tc.%s%s = bytes.Clone(%s)", f.name, value, a))
+			eq = sprintf(`bytes.Equal(%s, %s)`, a, b)
+		case `[]any`:
+			clone.WriteString(sprintf("\n\tc.%s = append([]any(nil), %s...)", f.name, a))
+			eq = sprintf(`reflect.DeepEqual(%s, %s)`, a, b)
+		case `any`:
+			// A BLOB is scanned as []byte, which is not comparable.
+			clone.WriteString(sprintf("\n\tif b, ok := %s.([]byte); ok {\n\t\tc.%s = bytes.Clone(b)\n\t}", a, f.name))
+			eq = sprintf(`reflect.DeepEqual(%s, %s)`, a, b)
+		case `*big.Int`:
+			clone.WriteString(sprintf("\n\tif %s != nil {\n\t\tc.%s = new(big.Int).Set(%[1]s)\n\t}", a, f.name))
+			eq = sprintf(`(%s == nil) == (%s == nil) && (%[1]s == nil || %[1]s.Cmp(%[2]s) == 0)`, a, b)
+		case `time.Time`:
+			eq = sprintf(`%s.Equal(%s)`, a, b)
+		default:
+			eq = sprintf(`%s == %s`, a, b)
+		}
+		if value != `` {
+			eq = sprintf(`u.%s.Valid == other.%[1]s.Valid && (!u.%[1]s.Valid || %s)`, f.name, eq)
+		}
+		equal = append(equal, eq)
+	}
+	structInfo[`clone`] = clone.String()
+	structInfo[`equal`] = strings.Join(equal, " &&\n\t\t")
+}
+
 var alignTable = map[string]int{
 	// Основни типове
 	"bool":    1,
@@ -643,9 +1281,11 @@ var alignTable = map[string]int{
 	"int":     8, // 64-бит
 	"string":  8,
 	"[]byte":  8,
+	"any":     8,
 
 	// Често срещани типове
 	"time.Time": 8,
+	"rx.UUID":   1,
 
 	// Класически Null типове
 	"sql.NullInt64":   8,
@@ -667,6 +1307,7 @@ var alignTable = map[string]int{
 	"sql.Null[string]":    8, // string е pointer+len, align=8
 	"sql.Null[time.Time]": 8,
 	"sql.Null[[]byte]":    8,
+	"sql.Null[rx.UUID]":   1,
 }
 
 var sizeTable = map[string]int{
@@ -684,9 +1325,11 @@ var sizeTable = map[string]int{
 	"int":     8,
 	"string":  16,
 	"[]byte":  24,
+	"any":     16,
 
 	// Често срещани типове
 	"time.Time": 24,
+	"rx.UUID":   16,
 
 	// Класически Null типове
 	"sql.NullInt64":   16,
@@ -708,4 +1351,5 @@ var sizeTable = map[string]int{
 	"sql.Null[string]":    32,
 	"sql.Null[time.Time]": 32,
 	"sql.Null[[]byte]":    40,
+	"sql.Null[rx.UUID]":   17,
 }
@@ -0,0 +1,78 @@
+package rx
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+/*
+UUID is a UUID, stored in the database as 16 bytes in BLOB(16) or BINARY(16)
+columns, and formatted as the canonical string
+`xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx` in Go and JSON. Use it as the type of
+fields, mapped to such columns. [Generate] maps them to UUID, if
+[GenerateOptions.BinaryUUIDs] is true.
+*/
+type UUID [16]byte
+
+// ParseUUID parses the canonical form of a UUID. The dashes are optional and
+// the hexadecimal digits may be in any case.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	h := strings.ReplaceAll(s, `-`, ``)
+	if len(h) != 2*len(u) {
+		return u, fmt.Errorf(`invalid UUID %q`, s)
+	}
+	if _, err := hex.Decode(u[:], []byte(h)); err != nil {
+		return u, fmt.Errorf(`invalid UUID %q: %w`, s, err)
+	}
+	return u, nil
+}
+
+// String returns the canonical form of u in lower case.
+func (u UUID) String() string {
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	hex.Encode(b[9:13], u[4:6])
+	hex.Encode(b[14:18], u[6:8])
+	hex.Encode(b[19:23], u[8:10])
+	hex.Encode(b[24:], u[10:])
+	b[8], b[13], b[18], b[23] = '-', '-', '-', '-'
+	return string(b[:])
+}
+
+// Value implements [driver.Valuer]. It returns the 16 bytes of u.
+func (u UUID) Value() (driver.Value, error) {
+	return u[:], nil
+}
+
+// Scan implements [sql.Scanner]. It accepts 16 bytes and the canonical form
+// as a string or bytes, so the column can also be a text one.
+func (u *UUID) Scan(src any) (err error) {
+	switch v := src.(type) {
+	case []byte:
+		if len(v) == len(u) {
+			copy(u[:], v)
+			return nil
+		}
+		*u, err = ParseUUID(string(v))
+	case string:
+		*u, err = ParseUUID(v)
+	default:
+		err = fmt.Errorf(`cannot scan %T into rx.UUID`, src)
+	}
+	return err
+}
+
+// MarshalText implements [encoding.TextMarshaler], so u is formatted as the
+// canonical string in JSON.
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+func (u *UUID) UnmarshalText(text []byte) (err error) {
+	*u, err = ParseUUID(string(text))
+	return err
+}